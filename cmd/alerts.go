@@ -1,54 +1,55 @@
 package cmd
 
 import (
-	"fmt"
+	"context"
 	"math"
 	"net/url"
-	"strconv"
+	"time"
 
 	"github.com/cli/go-gh/v2/pkg/api"
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	alertsCmd.PersistentFlags().BoolVar(&syncOnly, "sync", false, "Only fetch the alerts updated since the previous --sync run, using the local history")
+	addAlertFilterFlags(alertsCmd)
+}
+
 var alertsCmd = &cobra.Command{
 	Use:   "alerts [flags]",
 	Short: "Get secret scanning alerts for an enterprise, organization, or repository",
+	PreRunE: func(cmd *cobra.Command, args []string) (err error) {
+		if err = validateSyncFlags(); err != nil {
+			return err
+		}
+		// a sync only fetches what changed, so unless a limit was asked for it fetches all of it:
+		if syncOnly && !cmd.Flags().Changed("limit") {
+			limit = math.MaxInt
+		}
+		return nil
+	},
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		return runAlerts(cmd, args)
 	},
 }
 
 func runAlerts(cmd *cobra.Command, args []string) (err error) {
-	// set scope & target based on the flag that was used:
-	scope, target, err := getScopeAndTarget()
+	// set the targets based on the flags that were used:
+	targets, err := getAlertTargets()
 	if err != nil {
 		return err
 	}
 
-	// set the API URL based on the target:
-	requestPath, err := createGitHubSecretAlertsAPIPath(scope, target)
+	opts := setOptions()
+	client, err := api.NewRESTClient(opts)
 	if err != nil {
 		return err
 	}
 
-	// update the URL to include query parameters based on specified flags:
-	parsedURL, err := url.Parse(requestPath)
-	if err != nil {
-		return err
-	}
-	values := parsedURL.Query()
+	// set the query parameters based on specified flags:
+	values := url.Values{}
 
-	var per_page string
-	if limit < 100 {
-		per_page = strconv.Itoa(limit)
-	} else {
-		per_page = "100"
-	}
-	per_page_int, err := strconv.Atoi(per_page)
-	if err != nil {
-		return err
-	}
-	values.Set("per_page", per_page)
+	setPaginationParameters(values)
 	// if provider was specified, filter results. Otherwise, return all results:
 	var secret_type string
 	if provider != "" {
@@ -57,58 +58,75 @@ func runAlerts(cmd *cobra.Command, args []string) (err error) {
 		secret_type = ""
 	}
 	values.Set("secret_type", secret_type)
-	parsedURL.RawQuery = values.Encode()
-
-	// update the request path
-	requestPath = parsedURL.String()
+	// add the alert filters specified by flags:
+	if err = setAlertFilterParameters(client, values); err != nil {
+		return err
+	}
+	if syncOnly {
+		setSyncParameters(values)
+	}
 
-	// loop through calls to the API until all pages of results have been fetched or limit has been reached:
-	var allSecretAlerts []Alert
-	var pageOfSecretAlerts []Alert
-	var pages = int(math.Ceil(float64(limit) / float64(per_page_int)))
+	// record the alerts, and their verification outcomes, in the local history:
+	store, err := openStateStore()
+	if err != nil {
+		return err
+	}
+	defer store.close()
+	runTime := time.Now()
 
-	opts := setOptions()
-	client, err := api.NewRESTClient(opts)
+	// request the alerts of each target, saving each page as it is fetched so that the run can be resumed
+	// if it stops part way through:
+	sources, err := newAlertSources(cmd.Name(), targets, values)
 	if err != nil {
 		return err
 	}
+	// write the alerts of this run to a snapshot as they arrive, for comparison with other runs:
+	snapshot, err := store.beginSnapshot(cmd.Name(), targets, sources, runTime)
+	if err != nil {
+		return err
+	}
+	// only fetch the alerts that changed since the previous sync:
+	if syncOnly {
+		if err = store.loadSyncCursors(sources); err != nil {
+			return err
+		}
+	}
+
+	// fetch pages of alerts in the background and report on each page as it arrives:
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+	pages := make(chan []Alert, 1)
+	fetchErr := make(chan error, 1)
+	go func() {
+		fetchErr <- fetchAlertPages(ctx, client, sources, pages)
+	}()
 
-	for page := 1; page <= pages; page++ {
-		fmt.Println("Processing page: " + strconv.Itoa(page))
-		_, nextPage, err := callGitHubAPI(client, requestPath, &pageOfSecretAlerts, GET)
-		if err != nil {
+	report := newAlertReport(client, reportScope(targets), false)
+	defer report.close()
+	for page := range pages {
+		if err = report.add(page); err != nil {
 			return err
 		}
-		// add each secret alert in the response page to allSecretAlerts array
-		allSecretAlerts = append(allSecretAlerts, pageOfSecretAlerts...)
-		var hasNextPage bool
-		if requestPath, hasNextPage = findNextPage(nextPage); !hasNextPage {
-			break
+		if err = store.record(page, runTime); err != nil {
+			return err
 		}
-		if page*per_page_int >= limit {
-			break
+		if err = snapshot.add(page); err != nil {
+			return err
 		}
 	}
-
-	// sort allSecretAlerts by repository name, and then by secret alert ID:
-	sortedAlerts := sortAlerts(allSecretAlerts)
-
-	// if a specific repo endpoint was targeted, add the repo field to the alerts:
-	if repository != "" {
-		sortedAlerts = addRepoFullNameToAlerts(sortedAlerts)
+	if err = <-fetchErr; err != nil {
+		return err
 	}
-
-	// pretty print all of the response details:
-	if !quiet {
-		err = prettyPrintAlerts(sortedAlerts, false)
+	if err = report.finish(); err != nil {
+		return err
 	}
-
-	// optionally generate a csv report of the results:
-	if len(sortedAlerts) > 0 && csvReport {
-		err = generateCSVReport(sortedAlerts, scope, false)
-		if err != nil {
+	if err = snapshot.finish(); err != nil {
+		return err
+	}
+	if syncOnly {
+		if err = store.saveSyncCursors(sources, runTime); err != nil {
 			return err
 		}
 	}
-	return
+	return removeCheckpoints(sources)
 }
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cli/go-gh/v2/pkg/config"
+)
+
+var resume bool
+
+var errNoCheckpoint = errors.New("there is no checkpoint to resume")
+
+// checkpointPage is one line of a checkpoint file: a page of fetched alerts, and the Link header URL of
+// the page after it (empty once the last page has been fetched).
+type checkpointPage struct {
+	Next_page string  `json:"next_page"`
+	Alerts    []Alert `json:"alerts"`
+}
+
+// checkpoint records every page of alerts as it is fetched, so that a run that stops part way through can
+// continue with --resume instead of starting over. Pages are appended one JSON line at a time, so that
+// saving a page doesn't rewrite the pages before it. The file is removed once the run succeeds.
+type checkpoint struct {
+	filename string
+	file     *os.File
+	encoder  *json.Encoder
+}
+
+func checkpointFilename(command string, requestPath string) string {
+	// a run can only be resumed by the same command, against the same host, with the same filters:
+	sum := sha256.Sum256([]byte(host + " " + command + " " + requestPath))
+	return filepath.Join(config.StateDir(), "gh-secret-scanning", "checkpoint-"+hex.EncodeToString(sum[:8])+".ndjson")
+}
+
+func newCheckpoint(command string, requestPath string) *checkpoint {
+	return &checkpoint{filename: checkpointFilename(command, requestPath)}
+}
+
+// restore reads the pages saved by a previous run, returning the alerts fetched so far and the URL of
+// the next page to fetch.
+func (c *checkpoint) restore() (alerts []Alert, nextPage string, err error) {
+	file, err := os.Open(c.filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, "", errNoCheckpoint
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	// a page of 100 alerts with locations can be much longer than the default line limit:
+	scanner.Buffer(make([]byte, 0, 1024*1024), 64*1024*1024)
+	var saved int64
+	for scanner.Scan() {
+		var page checkpointPage
+		if err = json.Unmarshal(scanner.Bytes(), &page); err != nil {
+			// the last line may have been cut short when the previous run stopped, drop it so that the
+			// pages saved from here on are appended after the last complete one:
+			file.Close()
+			return alerts, nextPage, os.Truncate(c.filename, saved)
+		}
+		saved += int64(len(scanner.Bytes())) + 1
+		alerts = append(alerts, page.Alerts...)
+		nextPage = page.Next_page
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, "", err
+	}
+	return alerts, nextPage, nil
+}
+
+// save appends a page of alerts. The checkpoint file is created on the first save, or appended to when
+// resuming.
+func (c *checkpoint) save(alerts []Alert, nextPage string) (err error) {
+	if c.file == nil {
+		if err = os.MkdirAll(filepath.Dir(c.filename), 0700); err != nil {
+			return err
+		}
+		// alerts include secret values, so only the current user may read the file:
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if resume {
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+		if c.file, err = os.OpenFile(c.filename, flags, 0600); err != nil {
+			return fmt.Errorf("unable to create checkpoint: %w", err)
+		}
+		c.encoder = json.NewEncoder(c.file)
+	}
+	return c.encoder.Encode(checkpointPage{Next_page: nextPage, Alerts: alerts})
+}
+
+func (c *checkpoint) close() {
+	if c.file != nil {
+		c.file.Close()
+		c.file = nil
+	}
+}
+
+// remove deletes the checkpoint once the run has completed.
+func (c *checkpoint) remove() error {
+	c.close()
+	if err := os.Remove(c.filename); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
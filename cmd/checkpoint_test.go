@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCheckpointRestore(t *testing.T) {
+	page1 := `{"next_page":"https://api.github.com/orgs/o/secret-scanning/alerts?after=a","alerts":[{"number":1},{"number":2}]}` + "\n"
+	page2 := `{"next_page":"https://api.github.com/orgs/o/secret-scanning/alerts?after=b","alerts":[{"number":3}]}` + "\n"
+	last := `{"next_page":"","alerts":[{"number":4}]}` + "\n"
+	tests := []struct {
+		name         string
+		content      string
+		wantNumbers  []int
+		wantNextPage string
+		wantContent  string
+	}{
+		{"empty", "", nil, "", ""},
+		{"complete pages", page1 + page2, []int{1, 2, 3}, "https://api.github.com/orgs/o/secret-scanning/alerts?after=b", page1 + page2},
+		{"last page", page1 + page2 + last, []int{1, 2, 3, 4}, "", page1 + page2 + last},
+		{"page cut short", page1 + page2 + last[:20], []int{1, 2, 3}, "https://api.github.com/orgs/o/secret-scanning/alerts?after=b", page1 + page2},
+		{"first page cut short", page1[:30], nil, "", ""},
+	}
+	for _, test := range tests {
+		filename := filepath.Join(t.TempDir(), "checkpoint.ndjson")
+		if err := os.WriteFile(filename, []byte(test.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		c := &checkpoint{filename: filename}
+		alerts, nextPage, err := c.restore()
+		if err != nil {
+			t.Errorf("%s: restore() failed: %v", test.name, err)
+			continue
+		}
+		var numbers []int
+		for _, alert := range alerts {
+			numbers = append(numbers, alert.Number)
+		}
+		if !slices.Equal(numbers, test.wantNumbers) || nextPage != test.wantNextPage {
+			t.Errorf("%s: restore() = %v, %q, want %v, %q", test.name, numbers, nextPage, test.wantNumbers, test.wantNextPage)
+		}
+		// a line that was cut short is removed, so that the pages saved next follow the last complete one:
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != test.wantContent {
+			t.Errorf("%s: checkpoint file is %q, want %q", test.name, content, test.wantContent)
+		}
+	}
+}
+
+func TestCheckpointRestoreMissing(t *testing.T) {
+	c := &checkpoint{filename: filepath.Join(t.TempDir(), "checkpoint.ndjson")}
+	if _, _, err := c.restore(); !errors.Is(err, errNoCheckpoint) {
+		t.Errorf("restore() = %v, want %v", err, errNoCheckpoint)
+	}
+}
+
+func TestCheckpointResumeAfterCutShortPage(t *testing.T) {
+	resume = true
+	defer func() { resume = false }()
+	filename := filepath.Join(t.TempDir(), "checkpoint.ndjson")
+	content := `{"next_page":"next","alerts":[{"number":1}]}` + "\n" + `{"next_page":"ne`
+	if err := os.WriteFile(filename, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c := &checkpoint{filename: filename}
+	if _, _, err := c.restore(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.save([]Alert{{Number: 2}}, ""); err != nil {
+		t.Fatal(err)
+	}
+	c.close()
+	alerts, nextPage, err := (&checkpoint{filename: filename}).restore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 2 || alerts[0].Number != 1 || alerts[1].Number != 2 || nextPage != "" {
+		t.Errorf("restore() after resuming = %v, %q, want alerts 1 and 2 and no next page", alerts, nextPage)
+	}
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// CODEOWNERS files are looked up in the same locations, and order, as GitHub does:
+var codeOwnersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+type codeOwnersRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+type fileContent struct {
+	Content  string `json:"content"`
+	Encoding string `json:"encoding"`
+}
+
+func fetchCodeOwners(client *api.RESTClient, repo string) (rules []codeOwnersRule, err error) {
+	for _, path := range codeOwnersPaths {
+		var content fileContent
+		statusCode, _, err := callGitHubAPI(client, "repos/"+repo+"/contents/"+path, &content, GET)
+		if statusCode == http.StatusNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		data, err := base64.StdEncoding.DecodeString(content.Content)
+		if err != nil {
+			return nil, err
+		}
+		return parseCodeOwners(string(data)), nil
+	}
+	// a repository without a CODEOWNERS file simply has no owners:
+	return nil, nil
+}
+
+func parseCodeOwners(content string) (rules []codeOwnersRule) {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// strip trailing comments:
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		pattern, err := codeOwnersPattern(fields[0])
+		if err != nil {
+			continue
+		}
+		rules = append(rules, codeOwnersRule{pattern: pattern, owners: fields[1:]})
+	}
+	return rules
+}
+
+func codeOwnersPattern(pattern string) (*regexp.Regexp, error) {
+	// CODEOWNERS patterns mostly follow gitignore rules: a leading or inner slash anchors the pattern to
+	// the repository root, a trailing slash matches everything in the directory, * matches within a path
+	// segment and ** matches across segments.
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	directory := strings.HasSuffix(pattern, "/")
+	pattern = strings.Trim(pattern, "/")
+	if pattern == "" {
+		return nil, errors.New("empty pattern")
+	}
+	var expression strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expression.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expression.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			expression.WriteString("[^/]*")
+		case pattern[i] == '?':
+			expression.WriteString("[^/]")
+		default:
+			expression.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	prefix := "^"
+	if !anchored {
+		prefix = "^(?:.*/)?"
+	}
+	// a match also covers everything below it, except that a trailing "/*" only matches direct children:
+	suffix := "(?:/.*)?$"
+	if directory {
+		suffix = "/.*$"
+	} else if strings.HasSuffix(pattern, "/*") {
+		suffix = "$"
+	}
+	return regexp.Compile(prefix + expression.String() + suffix)
+}
+
+func ownersForPath(rules []codeOwnersRule, path string) []string {
+	// the last matching pattern takes precedence:
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].pattern.MatchString(path) {
+			return rules[i].owners
+		}
+	}
+	return nil
+}
+
+func codeOwnersForAlerts(rules []codeOwnersRule, alerts []Alert) (owners []string) {
+	seen := map[string]bool{}
+	for _, alert := range alerts {
+		for _, location := range alert.Locations {
+			if location.Type != "commit" {
+				continue
+			}
+			for _, owner := range ownersForPath(rules, location.Details.Path) {
+				if !seen[owner] {
+					seen[owner] = true
+					owners = append(owners, owner)
+				}
+			}
+		}
+	}
+	sort.Strings(owners)
+	return owners
+}
+
+func assignableOwners(owners []string) (logins []string) {
+	// only individual users can be assigned, not teams (@org/team) or email addresses:
+	for _, owner := range owners {
+		if strings.HasPrefix(owner, "@") && !strings.Contains(owner, "/") {
+			logins = append(logins, strings.TrimPrefix(owner, "@"))
+		}
+	}
+	return logins
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCodeOwnersPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*", "main.go", true},
+		{"*", "cmd/main.go", true},
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", true},
+		{"*.go", "main.go.txt", false},
+		{"config.yml", "deploy/config.yml", true},
+		{"/config.yml", "config.yml", true},
+		{"/config.yml", "deploy/config.yml", false},
+		{"docs/", "docs/README.md", true},
+		{"docs/", "src/docs/README.md", true},
+		{"docs/", "docs", false},
+		{"docs/*", "docs/README.md", true},
+		{"docs/*", "docs/api/README.md", false},
+		{"apps", "apps/web/index.js", true},
+		{"apps", "src/apps/web/index.js", true},
+		{"/build/logs/", "build/logs/today.log", true},
+		{"**/logs", "deep/build/logs/today.log", true},
+		{"**/logs", "logs/today.log", true},
+		{"src/**/secrets.env", "src/a/b/secrets.env", true},
+		{"src/**/secrets.env", "src/secrets.env", true},
+		{"src/**/secrets.env", "other/src/a/secrets.env", false},
+		{"key?.pem", "certs/key1.pem", true},
+		{"key?.pem", "certs/key10.pem", false},
+		{"a+b.txt", "a+b.txt", true},
+		{"a+b.txt", "aab.txt", false},
+	}
+	for _, test := range tests {
+		pattern, err := codeOwnersPattern(test.pattern)
+		if err != nil {
+			t.Errorf("codeOwnersPattern(%q): %v", test.pattern, err)
+			continue
+		}
+		if got := pattern.MatchString(test.path); got != test.want {
+			t.Errorf("codeOwnersPattern(%q) matching %q = %t, want %t", test.pattern, test.path, got, test.want)
+		}
+	}
+	if _, err := codeOwnersPattern("/"); err == nil {
+		t.Errorf("codeOwnersPattern(%q) should fail", "/")
+	}
+}
+
+func TestOwnersForPath(t *testing.T) {
+	rules := parseCodeOwners(`# default owners
+*       @o/everyone
+*.go    @o/gophers # Go code
+/docs/  @o/writers @docs-lead
+
+/cmd/secrets.go  @security
+`)
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"README.md", []string{"@o/everyone"}},
+		{"main.go", []string{"@o/gophers"}},
+		{"cmd/alerts.go", []string{"@o/gophers"}},
+		{"docs/setup.md", []string{"@o/writers", "@docs-lead"}},
+		{"docs/example.go", []string{"@o/writers", "@docs-lead"}},
+		{"cmd/secrets.go", []string{"@security"}},
+	}
+	for _, test := range tests {
+		if got := ownersForPath(rules, test.path); !slices.Equal(got, test.want) {
+			t.Errorf("ownersForPath(%q) = %v, want %v", test.path, got, test.want)
+		}
+	}
+	if got := ownersForPath(nil, "main.go"); got != nil {
+		t.Errorf("ownersForPath without rules = %v, want none", got)
+	}
+}
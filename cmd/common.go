@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"errors"
@@ -13,11 +14,11 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cli/go-gh/pkg/tableprinter"
 	"github.com/cli/go-gh/pkg/term"
-	"github.com/cli/go-gh/v2"
 	"github.com/cli/go-gh/v2/pkg/api"
 )
 
@@ -34,6 +35,7 @@ type Repository struct {
 type Alert struct {
 	Number                      int        `json:"number"`
 	Created_at                  string     `json:"created_at"`
+	Updated_at                  string     `json:"updated_at"`
 	URL                         string     `json:"url"`
 	HTML_URL                    string     `json:"html_url"`
 	State                       string     `json:"state"`
@@ -48,9 +50,11 @@ type Alert struct {
 	Push_protection_bypassed_at string     `json:"push_protection_bypassed_at"`
 	Push_protection_bypassed_by User       `json:"push_protection_bypassed_by"`
 	Validity_github             string     `json:"validity"`
-	Validity_boolean            bool       `json:"validity_boolean"`
+	Validity_outcome            Outcome    `json:"validity_outcome"`
+	Validity_reason             string     `json:"validity_reason"`
 	Validity_response_code      string     `json:"validity_response_code"`
 	Validity_endpoint           string     `json:"validity_endpoint"`
+	Locations                   []Location `json:"locations,omitempty"`
 }
 
 type HttpMethod int
@@ -59,6 +63,7 @@ const (
 	GET HttpMethod = iota
 	POST
 	PUT
+	PATCH
 	DELETE
 )
 
@@ -109,6 +114,25 @@ func Gray(s string) string {
 	return "\x1b[90m" + s + "\x1b[m"
 }
 
+func outcomeColor(outcome Outcome) func(string) string {
+	// highlight active secrets, and flag the ones that could not be checked:
+	switch outcome {
+	case OutcomeActive:
+		return Yellow
+	case OutcomeUnknown:
+		return Red
+	default:
+		return Gray
+	}
+}
+
+func confirm(prompt string) bool {
+	fmt.Fprintln(logOutput(), prompt)
+	var response string
+	fmt.Scanln(&response)
+	return strings.ToLower(response) == "y" || strings.ToLower(response) == "yes"
+}
+
 func setOptions() api.ClientOptions {
 	var opts api.ClientOptions
 	if quiet {
@@ -129,30 +153,59 @@ func setOptions() api.ClientOptions {
 }
 
 func callGitHubAPI(client *api.RESTClient, requestPath string, parseType interface{}, method HttpMethod, postBody ...[]byte) (int, string, error) {
+	return callGitHubAPIWithContext(context.Background(), client, requestPath, parseType, method, postBody...)
+}
+
+// callGitHubAPIWithContext is callGitHubAPI for requests that are part of a cancellable run, e.g. the
+// fetches of several targets, which stop as soon as one of them fails, even while waiting out a rate limit.
+func callGitHubAPIWithContext(ctx context.Context, client *api.RESTClient, requestPath string, parseType interface{}, method HttpMethod, postBody ...[]byte) (int, string, error) {
 	var httpMethod string
 	switch method {
 	case POST:
 		httpMethod = http.MethodPost
 	case PUT:
 		httpMethod = http.MethodPut
+	case PATCH:
+		httpMethod = http.MethodPatch
 	case DELETE:
 		httpMethod = http.MethodDelete
 	default:
 		httpMethod = http.MethodGet
 	}
 
-	var body io.Reader
-	if len(postBody) > 0 {
-		body = bytes.NewReader(postBody[0])
-	} else {
-		body = nil
-	}
+	var response *http.Response
+	for attempt := 0; ; attempt++ {
+		var body io.Reader
+		if len(postBody) > 0 {
+			body = bytes.NewReader(postBody[0])
+		} else {
+			body = nil
+		}
 
-	response, err := client.Request(httpMethod, requestPath, body)
-	if err != nil {
+		var err error
+		response, err = client.RequestWithContext(ctx, httpMethod, requestPath, body)
+		if err == nil {
+			break
+		}
+		// errors without a response, e.g. network failures, have no status code:
 		var httpError *api.HTTPError
-		errors.As(err, &httpError)
-		return httpError.StatusCode, "", err
+		if !errors.As(err, &httpError) {
+			return 0, "", err
+		}
+		// wait out primary and secondary rate limits instead of failing the whole run:
+		wait, throttled := apiThrottleWait(httpError, attempt)
+		if !throttled || attempt >= maxThrottleRetries {
+			return httpError.StatusCode, "", err
+		}
+		if !quiet {
+			fmt.Println(Yellow("Rate limited by " + host + ", retrying in " + wait.Round(time.Second).String() + "..."))
+		}
+		if err = sleepContext(ctx, wait); err != nil {
+			return httpError.StatusCode, "", err
+		}
+	}
+	if verbose && !quiet {
+		reportRateLimit(response.Header)
 	}
 
 	defer response.Body.Close()
@@ -216,7 +269,7 @@ func sortAlerts(alerts []Alert) []Alert {
 	return alerts
 }
 
-func addRepoFullNameToAlerts(alerts []Alert) []Alert {
+func addRepoFullNameToAlerts(alerts []Alert, repository string) []Alert {
 	// handle repo name for repo endpoint which doesn't return the repo name field
 	for i := range alerts {
 		alerts[i].Repository.Full_name = repository
@@ -238,73 +291,31 @@ func getSecretTypeParameter() (secret_type_param string) {
 	return secret_type_param
 }
 
-func getScopeAndTarget() (scope string, target string, err error) {
-	if enterprise != "" {
-		scope = "enterprise"
-		target = enterprise
-	} else if organization != "" {
-		scope = "organization"
-		target = organization
-	} else if repository != "" {
-		scope = "repository"
-		repoPattern := regexp.MustCompile(`^[^/]+/[^/]+$`)
-		if !repoPattern.MatchString(repository) {
-			err = errors.New("repository must follow the format 'owner/repository'")
-			return "", "", err
-		}
-		// check if secret scanning is enabled for the repository:
-		secretScanningEnabled, err := checkSecretScanningSetting(repository)
-		if err != nil {
-			return "", "", err
-		}
-		if !secretScanningEnabled {
-			err = errors.New("Secret scanning is not enabled for the repository: " + repository)
-			return "", "", err
-		}
-		target = repository
+func prettyPrintAlerts(alerts []Alert, validity_check bool) (err error) {
+	if err = printAlertsTable(alerts, validity_check, true); err != nil {
+		return err
 	}
-	return scope, target, err
+	if limit < len(alerts) {
+		fmt.Println(Blue("Fetched " + strconv.Itoa(limit) + " secret alerts."))
+	} else {
+		fmt.Println(Blue("Fetched " + strconv.Itoa(len(alerts)) + " secret alerts."))
+	}
+	return err
 }
 
-func prettyPrintAlerts(alerts []Alert, validity_check bool) (err error) {
+func printAlertsTable(alerts []Alert, validity_check bool, header bool) (err error) {
 	counter := 0
 	if len(alerts) > 0 {
 		terminal := term.FromEnv()
 		termWidth, _, _ := terminal.Size()
 		t := tableprinter.New(terminal.Out(), terminal.IsTerminalOutput(), termWidth)
-		t.AddField("Repository", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
-		t.AddField("ID", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
-		t.AddField("State", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
-		t.AddField("Secret Type", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
-		t.AddField("Validity GitHub", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
-		if secret {
-			t.AddField("Secret", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
-		}
-		if validity_check {
-			t.AddField("Confirmed Valid", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
-			t.AddField("Status Code", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
-			t.AddField("Validity Endpoint", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
-		}
-		if verbose {
-			t.AddField("Created At", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
-			t.AddField("Resolution", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
-			t.AddField("Resolved At", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
-			t.AddField("Resolved By", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
-			t.AddField("Push Protection Bypassed", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
-			t.AddField("Push Protection Bypassed At", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
-			t.AddField("Push Protection Bypassed By", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
-			t.AddField("URL", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+		if header {
+			addAlertsTableHeader(t, validity_check)
 		}
-		t.EndRow()
 
 		for counter < len(alerts) && counter < limit {
 			alert := alerts[counter]
-			var color func(string) string
-			if alert.Validity_boolean {
-				color = Yellow
-			} else {
-				color = Gray
-			}
+			color := outcomeColor(alert.Validity_outcome)
 			t.AddField(alert.Repository.Full_name, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
 			t.AddField(strconv.Itoa(alert.Number), tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
 			t.AddField(alert.State, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
@@ -313,8 +324,12 @@ func prettyPrintAlerts(alerts []Alert, validity_check bool) (err error) {
 			if secret {
 				t.AddField(alert.Secret, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
 			}
+			if showLocations {
+				t.AddField(summarizeLocations(alert.Locations), tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+			}
 			if validity_check {
-				t.AddField(strconv.FormatBool(alert.Validity_boolean), tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+				t.AddField(string(alert.Validity_outcome), tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+				t.AddField(alert.Validity_reason, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
 				t.AddField(alert.Validity_response_code, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
 				t.AddField(alert.Validity_endpoint, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
 			}
@@ -336,18 +351,63 @@ func prettyPrintAlerts(alerts []Alert, validity_check bool) (err error) {
 			return err
 		}
 	}
-	if limit < len(alerts) {
-		fmt.Println(Blue("Fetched " + strconv.Itoa(limit) + " secret alerts."))
-	} else {
-		fmt.Println(Blue("Fetched " + strconv.Itoa(len(alerts)) + " secret alerts."))
-	}
 	return err
 }
 
+func addAlertsTableHeader(t tableprinter.TablePrinter, validity_check bool) {
+	t.AddField("Repository", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+	t.AddField("ID", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+	t.AddField("State", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+	t.AddField("Secret Type", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+	t.AddField("Validity GitHub", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+	if secret {
+		t.AddField("Secret", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+	}
+	if showLocations {
+		t.AddField("Location", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+	}
+	if validity_check {
+		t.AddField("Verification", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+		t.AddField("Reason", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+		t.AddField("Status Code", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+		t.AddField("Validity Endpoint", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+	}
+	if verbose {
+		t.AddField("Created At", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+		t.AddField("Resolution", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+		t.AddField("Resolved At", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+		t.AddField("Resolved By", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+		t.AddField("Push Protection Bypassed", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+		t.AddField("Push Protection Bypassed At", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+		t.AddField("Push Protection Bypassed By", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+		t.AddField("URL", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+	}
+	t.EndRow()
+}
+
 func generateCSVReport(alerts []Alert, scope string, validity_check bool) (err error) {
-	fmt.Println(Blue("Generating CSV report..."))
-	// reset counter
-	counter := 0
+	report, err := newCSVReportWriter(scope, validity_check)
+	if err != nil {
+		return err
+	}
+	if err = report.writeAlerts(alerts); err != nil {
+		report.close()
+		return err
+	}
+	return report.close()
+}
+
+// csvReportWriter writes alerts to a csv report incrementally, so that streamed pages don't have to be buffered.
+type csvReportWriter struct {
+	file           *os.File
+	writer         *csv.Writer
+	filename       string
+	validity_check bool
+	counter        int
+}
+
+func newCSVReportWriter(scope string, validity_check bool) (report *csvReportWriter, err error) {
+	fmt.Fprintln(logOutput(), Blue("Generating CSV report..."))
 	// get current date & time:
 	now := time.Now()
 	// Format the time as YYYYMMDD-HHMMSS
@@ -356,286 +416,199 @@ func generateCSVReport(alerts []Alert, scope string, validity_check bool) (err e
 	// Create a CSV file
 	file, err := os.Create(filename)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer file.Close()
 	// Initialize CSV writer
 	writer := csv.NewWriter(file)
-	defer writer.Flush()
 	// Write headers to CSV file
 	headers := []string{"Repository", "ID", "State", "Secret Type", "GitHub Validity"}
 	if secret {
 		headers = append(headers, "Secret")
 	}
+	if showLocations {
+		headers = append(headers, "Locations")
+	}
 	if validity_check {
-		headers = append(headers, "Confirmed Valid", "Status Code", "Validation Endpoint")
+		headers = append(headers, "Verification", "Reason", "Status Code", "Validation Endpoint")
 	}
 	if verbose {
 		headers = append(headers, "Created At", "Resolution", "Resolved At", "Resolved By", "Push Protection Bypassed", "Push Protection Bypassed At", "Push Protection Bypassed By", "URL")
 	}
 	writer.Write(headers)
-	// Write data to CSV file
-	for counter < len(alerts) && counter < limit {
-		alert := alerts[counter]
+	return &csvReportWriter{file: file, writer: writer, filename: filename, validity_check: validity_check}, nil
+}
+
+func (r *csvReportWriter) writeAlerts(alerts []Alert) error {
+	// Write data to CSV file, up to the limit across all calls
+	for _, alert := range alerts {
+		if r.counter >= limit {
+			break
+		}
 		row := []string{alert.Repository.Full_name, strconv.Itoa(alert.Number), alert.State, alert.Secret_type, alert.Validity_github}
 		if secret {
 			row = append(row, alert.Secret)
 		}
-		if validity_check {
-			row = append(row, strconv.FormatBool(alert.Validity_boolean), alert.Validity_response_code, alert.Validity_endpoint)
+		if showLocations {
+			row = append(row, joinLocations(alert.Locations))
+		}
+		if r.validity_check {
+			row = append(row, string(alert.Validity_outcome), alert.Validity_reason, alert.Validity_response_code, alert.Validity_endpoint)
 		}
 		if verbose {
 			row = append(row, alert.Created_at, alert.Resolution, alert.Resolved_at, alert.Resolved_by.Login, strconv.FormatBool(alert.Push_protection_bypassed), alert.Push_protection_bypassed_at, alert.Push_protection_bypassed_by.Login, alert.HTML_URL)
 		}
-		writer.Write(row)
-		counter++
+		r.writer.Write(row)
+		r.counter++
+	}
+	r.writer.Flush()
+	return r.writer.Error()
+}
+
+func (r *csvReportWriter) close() (err error) {
+	r.writer.Flush()
+	err = r.writer.Error()
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
 	}
-	if err := writer.Error(); err != nil {
+	if err != nil {
 		return err
 	}
-	fmt.Println(Blue("CSV report generated: " + filename))
-	return err
+	fmt.Fprintln(logOutput(), Blue("CSV report generated: "+r.filename))
+	return nil
 }
 
-func verifyAlerts(alerts []Alert) (alertsOutput []Alert, err error) {
-	// Print Supported providers for reference when verbose flag is enabled
-	if verbose {
-		fmt.Println(Blue("Supported Providers:"))
-		for provider, secretTypes := range SupportedProviders {
-			for secretType := range secretTypes {
-				fmt.Printf("- %s - %s\n", provider, secretType)
-			}
+func printSupportedProviders() {
+	fmt.Fprintln(logOutput(), Blue("Supported Providers:"))
+	for provider, secretTypes := range SupportedProviders {
+		for secretType := range secretTypes {
+			fmt.Fprintf(logOutput(), "- %s - %s\n", provider, secretType)
 		}
 	}
-	for i, alert := range alerts {
-		// verify that the alert is valid by making a request to its validation endpoint:
-		provider := strings.Split(alert.Secret_type, "_")[0]
-
-		// Skip alert if provider is not supported
-		if _, ok := SupportedProviders[provider]; !ok {
-			continue
-		}
-
-		secret_type := alert.Secret_type
-		secret_validation_method := SupportedProviders[provider][secret_type]["HttpMethod"]
-		secret_validation_content_type := SupportedProviders[provider][secret_type]["ContentType"]
-		alert.Validity_endpoint = SupportedProviders[provider][secret_type]["ValidationEndpoint"]
+}
 
-		// create a new client for the validation request:
-		var opts api.ClientOptions
-		opts.AuthToken = alert.Secret
-		opts.Headers = map[string]string{
-			"User-Agent": "gh-secret-scanning",
-		}
-		client, err := api.NewHTTPClient(opts)
-		if err != nil {
-			return alerts, err
-		}
-		// send a request to the validation endpoint:
-		var response *http.Response
-		if secret_validation_method == "POST" {
-			var body io.Reader
-			req, _ := http.NewRequest("POST", alert.Validity_endpoint, body)
-			req.Header.Set("Authorization", "Bearer "+alert.Secret)
-			req.Header.Set("Content-Type", secret_validation_content_type)
-			req.Header.Set("User-Agent", "gh-secret-scanning")
-			response, err = client.Do(req)
-			if err != nil {
-				fmt.Println("WARNING: Unable to send " + secret_validation_method + " request to " + alert.Validity_endpoint)
-				continue
-			}
-			alert.Validity_response_code = strconv.Itoa(response.StatusCode)
-			defer response.Body.Close()
-		} else if secret_validation_method == "GET" {
-			response, err = client.Get(alert.Validity_endpoint)
-			if err != nil {
-				fmt.Println("WARNING: Unable to send " + secret_validation_method + " request to " + alert.Validity_endpoint)
-				continue
-			}
-			alert.Validity_response_code = strconv.Itoa(response.StatusCode)
-		} else {
-			fmt.Println("WARNING: Invalid HTTP method for validation endpoint for " + alert.Secret_type + " secret type.")
-			continue
-		}
-		expected_body_key := SupportedProviders[provider][secret_type]["ExpectedBodyKey"]
-		expected_body_value := SupportedProviders[provider][secret_type]["ExpectedBodyValue"]
-		if expected_body_key != "" {
-			alert.Validity_boolean = checkForExpectedBody(response, expected_body_key, expected_body_value, alert)
-		} else if alert.Validity_response_code == "200" {
-			alert.Validity_boolean = true
-			if verbose {
-				fmt.Println(Yellow("CONFIRMED: Alert " + strconv.Itoa(alert.Number) + " in " + alert.Repository.Full_name + " is valid."))
-			}
-		} else {
-			alert.Validity_boolean = false
-		}
-		if provider == "github" && !alert.Validity_boolean && host != "github.com" {
-			// also confirm validity with the provided GitHub Enterprise Server API:
-			alert = checkEnterpriseServerAPI(alert, client, secret_validation_method, secret_validation_content_type)
-			if alert.Validity_response_code == "200" {
-				alert.Validity_boolean = true
-				if verbose {
-					fmt.Println(Yellow("CONFIRMED: Alert " + strconv.Itoa(alert.Number) + " in " + alert.Repository.Full_name + " is valid."))
+func verifyAlerts(ctx context.Context, alerts []Alert) (alertsOutput []Alert, err error) {
+	// fan the alerts out over a bounded pool of workers. Each worker writes back to the alert's own
+	// index, so the sorted order of the input is preserved:
+	workers := max(concurrency, 1)
+	indexes := make(chan int)
+	var errs []error
+	var errsMutex sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, len(alerts)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				alert, verifyErr := verifyAlert(ctx, alerts[i])
+				alerts[i] = alert
+				if verifyErr != nil {
+					errsMutex.Lock()
+					errs = append(errs, verifyErr)
+					errsMutex.Unlock()
 				}
-			} else {
-				alert.Validity_boolean = false
 			}
-		}
-		alerts[i] = alert
+		}()
 	}
-	return alerts, err
+	for i := range alerts {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return alerts, errors.Join(errs...)
 }
 
-func checkForExpectedBody(response *http.Response, expected_body_key string, expected_body_value string, alert Alert) (validity_boolean bool) {
-	body, err := io.ReadAll(response.Body)
-	if err != nil {
-		fmt.Println("ERROR: Unable to read response body for alert " + strconv.Itoa(alert.Number) + " in " + alert.Repository.Full_name)
-		return false
-	}
-	var response_body map[string]interface{}
-	err = json.Unmarshal(body, &response_body)
-	if err != nil {
-		fmt.Println("ERROR: Unable to unmarshal response body for alert " + strconv.Itoa(alert.Number) + " in " + alert.Repository.Full_name)
-		return false
+func verifyAlert(ctx context.Context, alert Alert) (Alert, error) {
+	// record why alerts without a validator or secret value were not checked:
+	_, validator, ok := lookupValidator(alert.Secret_type)
+	if !ok {
+		alert.Validity_outcome = OutcomeUnsupported
+		alert.Validity_reason = "no validator for " + alert.Secret_type
+		return alert, nil
 	}
-	body_value := response_body[expected_body_key]
-	if body_value.(bool) {
-		// convert response_value to a string
-		body_value = strconv.FormatBool(body_value.(bool))
+	if alert.Secret == "" {
+		alert.Validity_outcome = OutcomeSkipped
+		alert.Validity_reason = "secret value not available"
+		return alert, nil
 	}
-	if body_value == expected_body_value {
-		alert.Validity_boolean = true
-		if verbose {
-			fmt.Println(Yellow("CONFIRMED: Alert " + strconv.Itoa(alert.Number) + " in " + alert.Repository.Full_name + " is valid."))
-		}
-	} else {
-		alert.Validity_boolean = false
-	}
-	return alert.Validity_boolean
-}
 
-func checkEnterpriseServerAPI(alert Alert, client *http.Client, secret_validation_method string, secret_validation_content_type string) (alertOutput Alert) {
-	enterprise_server_api_endpoint := "https://" + host + "/api/v3/"
-	// create a new http request:
-	req, _ := http.NewRequest("GET", enterprise_server_api_endpoint, nil)
-	req.Header.Set("Authorization", "Bearer "+alert.Secret)
-	req.Header.Set("Content-Type", secret_validation_content_type)
-	req.Header.Set("User-Agent", "gh-secret-scanning")
-	response, err := client.Do(req)
+	// verify that the alert is valid using its provider's validator:
+	result, err := validator.Validate(ctx, alert)
+	alert.Validity_endpoint = result.Endpoint
+	if result.StatusCode != 0 {
+		alert.Validity_response_code = strconv.Itoa(result.StatusCode)
+	}
 	if err != nil {
-		fmt.Println("WARNING: Unable to send " + secret_validation_method + " request to " + alert.Validity_endpoint)
+		fmt.Fprintln(logOutput(), "WARNING: Unable to verify alert "+strconv.Itoa(alert.Number)+" in "+alert.Repository.Full_name+": "+err.Error())
+		alert.Validity_outcome = OutcomeUnknown
+		alert.Validity_reason = err.Error()
+		return alert, err
+	}
+	alert.Validity_outcome = result.Outcome
+	alert.Validity_reason = result.Reason
+	if alert.Validity_outcome == OutcomeActive && verbose {
+		fmt.Fprintln(logOutput(), Yellow("CONFIRMED: Alert "+strconv.Itoa(alert.Number)+" in "+alert.Repository.Full_name+" is valid."))
 	}
-	alert.Validity_response_code = strconv.Itoa(response.StatusCode)
-	alert.Validity_endpoint = enterprise_server_api_endpoint
-	return alert
+	return alert, nil
 }
 
-func createIssuesForValidAlerts(alerts []Alert) (err error) {
-	fmt.Println(Blue("Creating issues for valid alerts..."))
-	issue_count := 0
-	alertsByRepo := make(map[string][]Alert)
-	for _, alert := range alerts {
-		alertsByRepo[alert.Repository.Full_name] = append(alertsByRepo[alert.Repository.Full_name], alert)
-	}
-	for repo, alerts := range alertsByRepo {
-		// check if there is at least one confirmed valid secret alert
-		hasValidAlert := false
-		for _, alert := range alerts {
-			if alert.Validity_boolean {
-				hasValidAlert = true
-				break
-			}
-		}
-		// if there is no confirmed valid secret alert, skip this repository
-		if !hasValidAlert {
-			continue
-		}
-		// create a string with the details of the alerts
-		details := "**Please promptly revoke the following secrets and confirm in the provider's logs that they have not been used maliciously:**\n\n"
-		details += "| Alert ID | Secret Type | Alert Link |\n"
-		details += "| --- | --- | --- |\n"
-		for _, alert := range alerts {
-			if alert.Validity_boolean {
-				details += fmt.Sprintf("| %d | %s | [Link](%s) |\n", alert.Number, alert.Secret_type, alert.HTML_URL)
-			}
-		}
-		// create the issue
-		var repo_with_host string
-		if host == "" {
-			repo_with_host = repo
-		} else {
-			repo_with_host = host + "/" + repo
-		}
-		_, _, err := gh.Exec("issue", "create", "--title", "IMMEDIATE ACTION REQUIRED: Active Secrets Detected", "--body", details, "--repo", repo_with_host)
-		if err != nil {
-			fmt.Println(err)
-		} else {
-			issue_count++
-			if verbose {
-				fmt.Println("Created issue in " + repo)
-			}
-		}
-	}
-	fmt.Println(Blue("Created " + strconv.Itoa(issue_count) + " issue(s)."))
-	return err
+// FeatureStatus is the enabled/disabled status of a single security_and_analysis feature.
+type FeatureStatus struct {
+	Status string `json:"status"`
 }
 
-func checkSecretScanningSetting(repository string) (secretScanningEnabled bool, err error) {
-	// create a new client for the request:
-	var opts api.ClientOptions
-	opts.Headers = map[string]string{
-		"User-Agent": "gh-secret-scanning",
-	}
-	client, err := api.NewHTTPClient(opts)
-	if err != nil {
-		return false, fmt.Errorf("unable to create HTTP client")
-	}
-	// make a GET request to the repository API:
-	response, err := client.Get("https://api.github.com/repos/" + repository)
+// SecurityAndAnalysis is only returned to callers with admin access to the repository, and
+// individual features are omitted when they don't apply (e.g. advanced_security for public repos).
+type SecurityAndAnalysis struct {
+	Advanced_security               *FeatureStatus `json:"advanced_security,omitempty"`
+	Secret_scanning                 *FeatureStatus `json:"secret_scanning,omitempty"`
+	Secret_scanning_push_protection *FeatureStatus `json:"secret_scanning_push_protection,omitempty"`
+	Secret_scanning_validity_checks *FeatureStatus `json:"secret_scanning_validity_checks,omitempty"`
+}
 
-	if err != nil {
-		return false, fmt.Errorf("unable to get repository information for " + repository)
-	}
-	defer response.Body.Close()
+type RepositorySettings struct {
+	Full_name             string               `json:"full_name"`
+	Visibility            string               `json:"visibility"`
+	Archived              bool                 `json:"archived"`
+	Security_and_analysis *SecurityAndAnalysis `json:"security_and_analysis"`
+}
 
-	// check if the response has a 200 status code:
-	if response.StatusCode != http.StatusOK {
-		return false, fmt.Errorf("non-200 status code received: %d", response.StatusCode)
-	}
+func securityAnalysisSettingsURL(repository string) string {
+	return "https://" + host + "/" + repository + "/settings/security_analysis"
+}
 
-	// parse the JSON response
-	var result map[string]interface{}
-	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
-		return false, fmt.Errorf("unable to parse JSON response")
+func checkSecretScanningSetting(repository string) (secretScanningEnabled bool, err error) {
+	// use the same authenticated client, and therefore host, as the alerts requests:
+	client, err := api.NewRESTClient(setOptions())
+	if err != nil {
+		return false, fmt.Errorf("unable to create REST client: %w", err)
 	}
-
-	// navigate to the required fields
-	securityAndAnalysis, ok := result["security_and_analysis"].(map[string]interface{})
-	if !ok {
-		return false, fmt.Errorf("security_and_analysis field not found")
+	var settings RepositorySettings
+	if _, _, err = callGitHubAPI(client, "repos/"+repository, &settings, GET); err != nil {
+		return false, fmt.Errorf("unable to get repository information for %s: %w", repository, err)
 	}
 
-	advancedSecurity, ok := securityAndAnalysis["advanced_security"].(map[string]interface{})
-	if !ok {
-		return false, fmt.Errorf("advanced_security field not found")
+	// the settings are only visible to repository admins, so let the alerts request decide otherwise:
+	securityAndAnalysis := settings.Security_and_analysis
+	if securityAndAnalysis == nil {
+		if !quiet {
+			fmt.Println(Yellow("WARNING: Unable to confirm the secret scanning settings for " + repository + " (admin access is required), continuing anyway."))
+		}
+		return true, nil
 	}
 
-	status, ok := advancedSecurity["status"].(string)
-	if !ok || status != "enabled" {
-		return false, fmt.Errorf("Advanced Security is not enabled for " + repository + Yellow("\nEnable GitHub Advanced Security for the repository here: https://github.com/"+repository+"/settings/security_analysis"))
+	if advancedSecurity := securityAndAnalysis.Advanced_security; advancedSecurity != nil && advancedSecurity.Status != "enabled" {
+		return false, fmt.Errorf("Advanced Security is not enabled for " + repository + Yellow("\nEnable GitHub Advanced Security for the repository here: "+securityAnalysisSettingsURL(repository)))
 	}
 
-	secretScanning, ok := securityAndAnalysis["secret_scanning"].(map[string]interface{})
-	if !ok {
-		return false, fmt.Errorf("secret_scanning field not found")
+	secretScanning := securityAndAnalysis.Secret_scanning
+	if secretScanning == nil {
+		if !quiet {
+			fmt.Println(Yellow("WARNING: Unable to confirm the secret scanning setting for " + repository + ", continuing anyway."))
+		}
+		return true, nil
 	}
-
-	secretScanningStatus, ok := secretScanning["status"].(string)
-	if !ok || secretScanningStatus != "enabled" {
-		return false, fmt.Errorf("secret scanning is not enabled for " + repository + Yellow("\nEnable secret scanning for the repository here: https://github.com/"+repository+"/settings/security_analysis"))
-	} else {
-		secretScanningEnabled = true
+	if secretScanning.Status != "enabled" {
+		return false, fmt.Errorf("secret scanning is not enabled for " + repository + Yellow("\nEnable secret scanning for the repository here: "+securityAnalysisSettingsURL(repository)))
 	}
-
-	return secretScanningEnabled, nil
+	return true, nil
 }
@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/cli/go-gh/pkg/tableprinter"
+	"github.com/cli/go-gh/pkg/term"
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/spf13/cobra"
+)
+
+// feature statuses that aren't returned by the API:
+const (
+	// the caller can't see the repository's security and analysis settings (admin access is required):
+	featureUnknown = "unknown"
+	// the feature doesn't apply to the repository, e.g. advanced_security for public repositories:
+	featureUnavailable = "unavailable"
+)
+
+var uncoveredOnly bool
+
+func init() {
+	coverageCmd.PersistentFlags().BoolVar(&uncoveredOnly, "uncovered", false, "Only report repositories where secret scanning is not enabled")
+}
+
+var coverageCmd = &cobra.Command{
+	Use:   "coverage [flags]",
+	Short: "Report secret scanning coverage for the repositories in an enterprise, organization, or repository",
+	Long:  "Report the GitHub Advanced Security, secret scanning, push protection and validity check status of every repository in an enterprise, organization, or repository. The status is only visible to repository admins, and is reported as unknown otherwise.",
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		return runCoverage(cmd, args)
+	},
+}
+
+type RepositoryCoverage struct {
+	Repository        string `json:"repository"`
+	Visibility        string `json:"visibility"`
+	Archived          bool   `json:"archived"`
+	Advanced_security string `json:"advanced_security"`
+	Secret_scanning   string `json:"secret_scanning"`
+	Push_protection   string `json:"push_protection"`
+	Validity_checks   string `json:"validity_checks"`
+}
+
+func (c RepositoryCoverage) covered() bool {
+	return c.Secret_scanning == "enabled"
+}
+
+func runCoverage(cmd *cobra.Command, args []string) (err error) {
+	// set the targets based on the flags that were used, without requiring secret scanning to be enabled:
+	targets, err := getTargets()
+	if err != nil {
+		return err
+	}
+
+	client, err := api.NewRESTClient(setOptions())
+	if err != nil {
+		return err
+	}
+	repositories, err := listTargetRepositories(client, targets)
+	if err != nil {
+		return err
+	}
+
+	var coverage []RepositoryCoverage
+	coveredCount := 0
+	for _, repository := range repositories {
+		repositoryCoverage := newRepositoryCoverage(repository)
+		if repositoryCoverage.covered() {
+			coveredCount++
+			if uncoveredOnly {
+				continue
+			}
+		}
+		coverage = append(coverage, repositoryCoverage)
+	}
+
+	// write JSON, or pretty print the coverage of each repository:
+	if jsonOutput() {
+		if ndjson {
+			err = writeNDJSON(os.Stdout, coverage)
+		} else {
+			err = writeJSON(os.Stdout, coverage)
+		}
+		if err != nil {
+			return err
+		}
+	} else if !quiet {
+		if err = printCoverageTable(coverage); err != nil {
+			return err
+		}
+		fmt.Println(Blue(strconv.Itoa(coveredCount) + " of " + strconv.Itoa(len(repositories)) + " repositories have secret scanning enabled."))
+	}
+
+	// optionally generate a csv report of the results:
+	if len(coverage) > 0 && csvReport {
+		err = generateCoverageCSVReport(coverage, reportScope(targets))
+	}
+	return err
+}
+
+func listTargetRepositories(client *api.RESTClient, targets []Target) (repositories []RepositorySettings, err error) {
+	// a repository is only listed once, even when it is targeted both directly and through its organization:
+	seen := map[string]bool{}
+	for _, target := range targets {
+		repositoriesOfTarget, err := listRepositories(client, target.Scope, target.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, repository := range repositoriesOfTarget {
+			if !seen[repository.Full_name] {
+				seen[repository.Full_name] = true
+				repositories = append(repositories, repository)
+			}
+		}
+	}
+	return repositories, nil
+}
+
+func listRepositories(client *api.RESTClient, scope string, target string) (repositories []RepositorySettings, err error) {
+	switch scope {
+	case "enterprise":
+		organizations, err := listEnterpriseOrganizations(target)
+		if err != nil {
+			return nil, err
+		}
+		for _, organization := range organizations {
+			organizationRepositories, err := listOrganizationRepositories(client, organization)
+			if err != nil {
+				return nil, err
+			}
+			repositories = append(repositories, organizationRepositories...)
+		}
+		return repositories, nil
+	case "organization":
+		return listOrganizationRepositories(client, target)
+	case "repository":
+		var repository RepositorySettings
+		if _, _, err = callGitHubAPI(client, "repos/"+target, &repository, GET); err != nil {
+			return nil, fmt.Errorf("unable to get repository information for %s: %w", target, err)
+		}
+		return []RepositorySettings{repository}, nil
+	}
+	return nil, fmt.Errorf("unsupported scope: %s", scope)
+}
+
+func listOrganizationRepositories(client *api.RESTClient, organization string) (repositories []RepositorySettings, err error) {
+	if !quiet {
+		fmt.Println("Processing organization: " + organization)
+	}
+	requestPath := "orgs/" + organization + "/repos?per_page=100"
+	for {
+		var pageOfRepositories []RepositorySettings
+		_, nextPage, err := callGitHubAPI(client, requestPath, &pageOfRepositories, GET)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list repositories for %s: %w", organization, err)
+		}
+		repositories = append(repositories, pageOfRepositories...)
+		var hasNextPage bool
+		if requestPath, hasNextPage = findNextPage(nextPage); !hasNextPage {
+			return repositories, nil
+		}
+	}
+}
+
+func listEnterpriseOrganizations(slug string) (organizations []string, err error) {
+	// there is no REST endpoint for the organizations in an enterprise:
+	client, err := api.NewGraphQLClient(setOptions())
+	if err != nil {
+		return nil, err
+	}
+	query := `query($slug: String!, $cursor: String) {
+		enterprise(slug: $slug) {
+			organizations(first: 100, after: $cursor) {
+				nodes { login }
+				pageInfo { hasNextPage endCursor }
+			}
+		}
+	}`
+	variables := map[string]interface{}{"slug": slug, "cursor": nil}
+	for {
+		var response struct {
+			Enterprise struct {
+				Organizations struct {
+					Nodes []struct {
+						Login string
+					}
+					PageInfo struct {
+						HasNextPage bool
+						EndCursor   string
+					}
+				}
+			}
+		}
+		if err = client.Do(query, variables, &response); err != nil {
+			return nil, fmt.Errorf("unable to list organizations for %s: %w", slug, err)
+		}
+		for _, organization := range response.Enterprise.Organizations.Nodes {
+			organizations = append(organizations, organization.Login)
+		}
+		if !response.Enterprise.Organizations.PageInfo.HasNextPage {
+			return organizations, nil
+		}
+		variables["cursor"] = response.Enterprise.Organizations.PageInfo.EndCursor
+	}
+}
+
+func newRepositoryCoverage(repository RepositorySettings) RepositoryCoverage {
+	coverage := RepositoryCoverage{
+		Repository: repository.Full_name,
+		Visibility: repository.Visibility,
+		Archived:   repository.Archived,
+	}
+	securityAndAnalysis := repository.Security_and_analysis
+	if securityAndAnalysis == nil {
+		coverage.Advanced_security = featureUnknown
+		coverage.Secret_scanning = featureUnknown
+		coverage.Push_protection = featureUnknown
+		coverage.Validity_checks = featureUnknown
+		return coverage
+	}
+	coverage.Advanced_security = featureStatus(securityAndAnalysis.Advanced_security)
+	coverage.Secret_scanning = featureStatus(securityAndAnalysis.Secret_scanning)
+	coverage.Push_protection = featureStatus(securityAndAnalysis.Secret_scanning_push_protection)
+	coverage.Validity_checks = featureStatus(securityAndAnalysis.Secret_scanning_validity_checks)
+	return coverage
+}
+
+func featureStatus(feature *FeatureStatus) string {
+	if feature == nil {
+		return featureUnavailable
+	}
+	return feature.Status
+}
+
+func coverageColor(coverage RepositoryCoverage) func(string) string {
+	switch coverage.Secret_scanning {
+	case "enabled":
+		return Green
+	case "disabled":
+		return Red
+	default:
+		return Yellow
+	}
+}
+
+func printCoverageTable(coverage []RepositoryCoverage) (err error) {
+	if len(coverage) == 0 {
+		return nil
+	}
+	terminal := term.FromEnv()
+	termWidth, _, _ := terminal.Size()
+	t := tableprinter.New(terminal.Out(), terminal.IsTerminalOutput(), termWidth)
+	t.AddField("Repository", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+	t.AddField("Visibility", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+	t.AddField("Archived", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+	t.AddField("Advanced Security", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+	t.AddField("Secret Scanning", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+	t.AddField("Push Protection", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+	t.AddField("Validity Checks", tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+	t.EndRow()
+	for _, repositoryCoverage := range coverage {
+		color := coverageColor(repositoryCoverage)
+		t.AddField(repositoryCoverage.Repository, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(repositoryCoverage.Visibility, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(strconv.FormatBool(repositoryCoverage.Archived), tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(repositoryCoverage.Advanced_security, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(repositoryCoverage.Secret_scanning, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(repositoryCoverage.Push_protection, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(repositoryCoverage.Validity_checks, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.EndRow()
+	}
+	if err := t.Render(); err != nil {
+		return fmt.Errorf("error rendering table: %v", err)
+	}
+	return nil
+}
+
+func generateCoverageCSVReport(coverage []RepositoryCoverage, scope string) (err error) {
+	fmt.Fprintln(logOutput(), Blue("Generating CSV report..."))
+	// Format the time as YYYYMMDD-HHMMSS
+	timestamp := time.Now().Format("20060102-150405")
+	filename := "SecretScanningCoverage-" + scope + "-" + timestamp + ".csv"
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"Repository", "Visibility", "Archived", "Advanced Security", "Secret Scanning", "Push Protection", "Validity Checks"})
+	for _, repositoryCoverage := range coverage {
+		writer.Write([]string{
+			repositoryCoverage.Repository,
+			repositoryCoverage.Visibility,
+			strconv.FormatBool(repositoryCoverage.Archived),
+			repositoryCoverage.Advanced_security,
+			repositoryCoverage.Secret_scanning,
+			repositoryCoverage.Push_protection,
+			repositoryCoverage.Validity_checks,
+		})
+	}
+	writer.Flush()
+	if err = writer.Error(); err != nil {
+		return err
+	}
+	fmt.Fprintln(logOutput(), Blue("CSV report generated: "+filename))
+	return nil
+}
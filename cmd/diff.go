@@ -0,0 +1,489 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/pkg/tableprinter"
+	"github.com/cli/go-gh/pkg/term"
+	"github.com/spf13/cobra"
+)
+
+// kinds of change between two snapshots:
+const (
+	changeNew          = "new"
+	changeNewlyActive  = "newly_active"
+	changeWentInactive = "went_inactive"
+	changeResolved     = "resolved"
+	// the alert is missing from the newer snapshot, e.g. because its repository was deleted, or the
+	// snapshots were taken with different filters or limits:
+	changeRemoved = "removed"
+)
+
+var listSnapshots bool
+
+func init() {
+	diffCmd.PersistentFlags().BoolVar(&listSnapshots, "list", false, "List the stored snapshots instead of comparing them")
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [<old> <new>] [flags]",
+	Short: "Compare two runs of an enterprise, organization, or repository",
+	Long:  "Show the alerts that are new, newly active, went inactive, resolved or removed between two snapshots. Each alerts and verify run stores a snapshot of the alerts it fetched. By default the last two snapshots of the targets are compared. Otherwise pass two snapshot IDs from --list, or two JSON or CSV reports produced by the alerts or verify subcommands.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 0 && len(args) != 2 {
+			return errors.New("diff takes either no arguments, or two snapshot IDs or report files to compare")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		return runDiff(cmd, args)
+	},
+}
+
+// AlertChange is a change to an alert between two snapshots.
+type AlertChange struct {
+	Change            string `json:"change"`
+	Repository        string `json:"repository"`
+	Number            int    `json:"number"`
+	Secret_type       string `json:"secret_type"`
+	HTML_URL          string `json:"html_url"`
+	State             string `json:"state"`
+	Resolution        string `json:"resolution"`
+	Validity          string `json:"validity"`
+	Previous_state    string `json:"previous_state"`
+	Previous_validity string `json:"previous_validity"`
+}
+
+func runDiff(cmd *cobra.Command, args []string) (err error) {
+	// targets are optional when comparing two given runs, and only narrow the alerts that are compared:
+	var targets []Target
+	if hasTargetFlags(cmd) {
+		if targets, err = getTargets(); err != nil {
+			return err
+		}
+	}
+
+	// the state store is only needed for snapshots, comparing two reports works without it:
+	var store *stateStore
+	if listSnapshots || len(args) == 0 || !isFile(args[0]) || !isFile(args[1]) {
+		if noState {
+			return errors.New("stored snapshots are not available with --no-state, compare two reports instead")
+		}
+		if store, err = openStateStore(); err != nil {
+			return err
+		}
+		defer store.close()
+	}
+	if listSnapshots {
+		if jsonOutput() {
+			return errors.New("--list doesn't support JSON output")
+		}
+		snapshots, err := store.snapshots()
+		if err != nil {
+			return err
+		}
+		return printSnapshotsTable(snapshots)
+	}
+
+	var oldLabel, newLabel string
+	var oldAlerts, newAlerts []SnapshotAlert
+	if len(args) == 0 {
+		latest, err := store.latestTargetSnapshots(targets, 2)
+		if err != nil {
+			return err
+		}
+		if len(latest) < 2 {
+			return errors.New("there are fewer than two snapshots of these targets taken with the same subcommand and filters, run the alerts or verify subcommand first")
+		}
+		if oldAlerts, err = store.snapshotAlerts(latest[1]); err != nil {
+			return err
+		}
+		if newAlerts, err = store.snapshotAlerts(latest[0]); err != nil {
+			return err
+		}
+		oldLabel, newLabel = snapshotLabel(latest[1]), snapshotLabel(latest[0])
+	} else {
+		if oldLabel, oldAlerts, err = loadDiffArgument(store, args[0]); err != nil {
+			return err
+		}
+		if newLabel, newAlerts, err = loadDiffArgument(store, args[1]); err != nil {
+			return err
+		}
+	}
+
+	if len(targets) > 0 {
+		oldAlerts, newAlerts = filterSnapshotAlerts(oldAlerts, targets), filterSnapshotAlerts(newAlerts, targets)
+	}
+	changes := diffSnapshotAlerts(oldAlerts, newAlerts)
+
+	// write JSON, or pretty print the changes:
+	if jsonOutput() {
+		if ndjson {
+			err = writeNDJSON(os.Stdout, changes)
+		} else {
+			err = writeJSON(os.Stdout, changes)
+		}
+		if err != nil {
+			return err
+		}
+	} else if !quiet {
+		fmt.Println(Blue("Comparing " + oldLabel + " with " + newLabel + ":"))
+		if err = printChangesTable(changes); err != nil {
+			return err
+		}
+		fmt.Println(Blue(summarizeChanges(changes)))
+	}
+
+	// optionally generate a csv report of the changes:
+	if len(changes) > 0 && csvReport {
+		err = generateDiffCSVReport(changes, reportScope(targets))
+	}
+	return err
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+func snapshotLabel(snapshot Snapshot) string {
+	return "snapshot " + strconv.FormatUint(snapshot.Id, 10) + " (" + formatTime(&snapshot.Taken_at) + ")"
+}
+
+// loadDiffArgument reads the alerts of a report file, or of a stored snapshot by ID.
+func loadDiffArgument(store *stateStore, arg string) (label string, alerts []SnapshotAlert, err error) {
+	if isFile(arg) {
+		alerts, err = loadReport(arg)
+		return filepath.Base(arg), alerts, err
+	}
+	id, err := strconv.ParseUint(arg, 10, 64)
+	if err != nil {
+		return "", nil, fmt.Errorf("%q is neither a report file nor a snapshot ID", arg)
+	}
+	snapshot, err := store.snapshot(id)
+	if err != nil {
+		return "", nil, err
+	}
+	if snapshot.Host != host {
+		return "", nil, fmt.Errorf("snapshot %d was taken on %s, not %s", id, snapshot.Host, host)
+	}
+	alerts, err = store.snapshotAlerts(snapshot)
+	return snapshotLabel(snapshot), alerts, err
+}
+
+func loadReport(path string) (alerts []SnapshotAlert, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		alerts, err = readCSVReport(file)
+	} else {
+		alerts, err = readJSONReport(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read report %s: %w", path, err)
+	}
+	return alerts, nil
+}
+
+func readJSONReport(r io.Reader) (alerts []SnapshotAlert, err error) {
+	// reports are either a JSON array of alerts, or NDJSON with one alert per line:
+	decoder := json.NewDecoder(r)
+	for {
+		var value json.RawMessage
+		if err = decoder.Decode(&value); err == io.EOF {
+			return alerts, nil
+		} else if err != nil {
+			return nil, err
+		}
+		var values []Alert
+		if strings.HasPrefix(strings.TrimSpace(string(value)), "[") {
+			err = json.Unmarshal(value, &values)
+		} else {
+			values = make([]Alert, 1)
+			err = json.Unmarshal(value, &values[0])
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, alert := range values {
+			alerts = append(alerts, newSnapshotAlert(alert))
+		}
+	}
+}
+
+func readCSVReport(r io.Reader) (alerts []SnapshotAlert, err error) {
+	reader := csv.NewReader(r)
+	// the columns depend on the flags the report was generated with, so they are looked up by header:
+	headers, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, header := range headers {
+		columns[header] = i
+	}
+	for _, required := range []string{"Repository", "ID"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing the %q column", required)
+		}
+	}
+	column := func(row []string, header string) string {
+		if i, ok := columns[header]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return alerts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		number, err := strconv.Atoi(column(row, "ID"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid alert ID %q", column(row, "ID"))
+		}
+		alerts = append(alerts, newSnapshotAlert(Alert{
+			Number:           number,
+			Repository:       Repository{Full_name: column(row, "Repository")},
+			State:            column(row, "State"),
+			Secret_type:      column(row, "Secret Type"),
+			Validity_github:  column(row, "GitHub Validity"),
+			Validity_outcome: Outcome(column(row, "Verification")),
+			Resolution:       column(row, "Resolution"),
+			HTML_URL:         column(row, "URL"),
+		}))
+	}
+}
+
+func filterSnapshotAlerts(alerts []SnapshotAlert, targets []Target) (filtered []SnapshotAlert) {
+	for _, alert := range alerts {
+		if repositoryMatchesTargets(alert.Repository, targets) {
+			filtered = append(filtered, alert)
+		}
+	}
+	return filtered
+}
+
+func snapshotAlertKey(alert SnapshotAlert) string {
+	return alert.Repository + "/" + strconv.Itoa(alert.Number)
+}
+
+// diffSnapshotAlerts lists what changed from the old alerts to the new ones. An alert can have several
+// changes, e.g. when its secret went inactive and the alert was resolved as revoked.
+func diffSnapshotAlerts(oldAlerts []SnapshotAlert, newAlerts []SnapshotAlert) (changes []AlertChange) {
+	previous := map[string]SnapshotAlert{}
+	for _, alert := range oldAlerts {
+		previous[snapshotAlertKey(alert)] = alert
+	}
+	current := map[string]bool{}
+	for _, alert := range newAlerts {
+		key := snapshotAlertKey(alert)
+		if current[key] {
+			continue
+		}
+		current[key] = true
+		old, seen := previous[key]
+		if !seen {
+			changes = append(changes, newAlertChange(changeNew, SnapshotAlert{}, alert))
+			continue
+		}
+		if alert.State == "resolved" && old.State != "resolved" {
+			changes = append(changes, newAlertChange(changeResolved, old, alert))
+		}
+		if alert.Validity == string(OutcomeActive) && old.Validity != string(OutcomeActive) {
+			changes = append(changes, newAlertChange(changeNewlyActive, old, alert))
+		}
+		if alert.Validity == string(OutcomeInactive) && old.Validity == string(OutcomeActive) {
+			changes = append(changes, newAlertChange(changeWentInactive, old, alert))
+		}
+	}
+	for _, alert := range oldAlerts {
+		key := snapshotAlertKey(alert)
+		if !current[key] {
+			current[key] = true
+			// the alert's current state isn't known:
+			removed := SnapshotAlert{Repository: alert.Repository, Number: alert.Number, Secret_type: alert.Secret_type, HTML_URL: alert.HTML_URL}
+			changes = append(changes, newAlertChange(changeRemoved, alert, removed))
+		}
+	}
+	return sortAlertChanges(changes)
+}
+
+func newAlertChange(change string, old SnapshotAlert, alert SnapshotAlert) AlertChange {
+	return AlertChange{
+		Change:            change,
+		Repository:        alert.Repository,
+		Number:            alert.Number,
+		Secret_type:       alert.Secret_type,
+		HTML_URL:          alert.HTML_URL,
+		State:             alert.State,
+		Resolution:        alert.Resolution,
+		Validity:          alert.Validity,
+		Previous_state:    old.State,
+		Previous_validity: old.Validity,
+	}
+}
+
+func sortAlertChanges(changes []AlertChange) []AlertChange {
+	// sort changes by repo name and then alert number, like alerts, and then by the kind of change:
+	order := []string{changeNew, changeNewlyActive, changeWentInactive, changeResolved, changeRemoved}
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Repository != changes[j].Repository {
+			return changes[i].Repository < changes[j].Repository
+		}
+		if changes[i].Number != changes[j].Number {
+			return changes[i].Number < changes[j].Number
+		}
+		return slices.Index(order, changes[i].Change) < slices.Index(order, changes[j].Change)
+	})
+	return changes
+}
+
+func summarizeChanges(changes []AlertChange) string {
+	counts := map[string]int{}
+	for _, change := range changes {
+		counts[change.Change]++
+	}
+	return strconv.Itoa(counts[changeNew]) + " new, " +
+		strconv.Itoa(counts[changeNewlyActive]) + " newly active, " +
+		strconv.Itoa(counts[changeWentInactive]) + " went inactive, " +
+		strconv.Itoa(counts[changeResolved]) + " resolved and " +
+		strconv.Itoa(counts[changeRemoved]) + " removed alerts."
+}
+
+func changeColor(change string) func(string) string {
+	switch change {
+	case changeNewlyActive:
+		return Red
+	case changeNew:
+		return Yellow
+	case changeWentInactive, changeResolved:
+		return Green
+	default:
+		return Gray
+	}
+}
+
+func printChangesTable(changes []AlertChange) (err error) {
+	if len(changes) == 0 {
+		fmt.Println(Blue("No changes."))
+		return nil
+	}
+	terminal := term.FromEnv()
+	termWidth, _, _ := terminal.Size()
+	t := tableprinter.New(terminal.Out(), terminal.IsTerminalOutput(), termWidth)
+	for _, header := range []string{"Change", "Repository", "ID", "Secret Type", "State", "Validity", "Previous State", "Previous Validity"} {
+		t.AddField(header, tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+	}
+	t.EndRow()
+	for _, change := range changes {
+		color := changeColor(change.Change)
+		t.AddField(change.Change, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(change.Repository, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(strconv.Itoa(change.Number), tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(change.Secret_type, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(change.State, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(change.Validity, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(change.Previous_state, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(change.Previous_validity, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.EndRow()
+	}
+	if err = t.Render(); err != nil {
+		return fmt.Errorf("error rendering table: %v", err)
+	}
+	return nil
+}
+
+func printSnapshotsTable(snapshots []Snapshot) (err error) {
+	if len(snapshots) == 0 {
+		fmt.Println(Blue("No snapshots stored yet, run the alerts or verify subcommand first."))
+		return nil
+	}
+	terminal := term.FromEnv()
+	termWidth, _, _ := terminal.Size()
+	t := tableprinter.New(terminal.Out(), terminal.IsTerminalOutput(), termWidth)
+	for _, header := range []string{"ID", "Taken At", "Command", "Targets", "Filters", "Alerts"} {
+		t.AddField(header, tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+	}
+	t.EndRow()
+	for _, snapshot := range snapshots {
+		var targetNames []string
+		for _, target := range snapshot.Targets {
+			targetNames = append(targetNames, target.Name)
+		}
+		t.AddField(strconv.FormatUint(snapshot.Id, 10), tableprinter.WithTruncate(nil))
+		t.AddField(formatTime(&snapshot.Taken_at), tableprinter.WithTruncate(nil))
+		t.AddField(snapshot.Command, tableprinter.WithTruncate(nil))
+		t.AddField(strings.Join(targetNames, ", "), tableprinter.WithTruncate(nil))
+		t.AddField(snapshotFilters(snapshot), tableprinter.WithTruncate(nil))
+		t.AddField(strconv.Itoa(snapshot.Alert_count), tableprinter.WithTruncate(nil))
+		t.EndRow()
+	}
+	if err = t.Render(); err != nil {
+		return fmt.Errorf("error rendering table: %v", err)
+	}
+	return nil
+}
+
+func snapshotFilters(snapshot Snapshot) string {
+	// every target of a run is requested with the same parameters, so they are shown once:
+	var filters []string
+	for _, query := range snapshot.Query {
+		_, parameters, _ := strings.Cut(query, "?")
+		if !slices.Contains(filters, parameters) {
+			filters = append(filters, parameters)
+		}
+	}
+	return strings.Join(filters, ", ")
+}
+
+func generateDiffCSVReport(changes []AlertChange, scope string) (err error) {
+	fmt.Fprintln(logOutput(), Blue("Generating CSV report..."))
+	// Format the time as YYYYMMDD-HHMMSS
+	timestamp := time.Now().Format("20060102-150405")
+	filename := "SecretScanningDiff-" + scope + "-" + timestamp + ".csv"
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"Change", "Repository", "ID", "Secret Type", "State", "Resolution", "Validity", "Previous State", "Previous Validity", "URL"})
+	for _, change := range changes {
+		writer.Write([]string{
+			change.Change,
+			change.Repository,
+			strconv.Itoa(change.Number),
+			change.Secret_type,
+			change.State,
+			change.Resolution,
+			change.Validity,
+			change.Previous_state,
+			change.Previous_validity,
+			change.HTML_URL,
+		})
+	}
+	writer.Flush()
+	if err = writer.Error(); err != nil {
+		return err
+	}
+	fmt.Fprintln(logOutput(), Blue("CSV report generated: "+filename))
+	return nil
+}
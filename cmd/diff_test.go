@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffSnapshotAlerts(t *testing.T) {
+	alert := func(number int, state string, validity string) SnapshotAlert {
+		return SnapshotAlert{Repository: "o/r", Number: number, Secret_type: "slack_api_token", State: state, Validity: validity}
+	}
+	change := func(change string, old SnapshotAlert, alert SnapshotAlert) AlertChange {
+		return newAlertChange(change, old, alert)
+	}
+	removed := func(number int) SnapshotAlert {
+		return SnapshotAlert{Repository: "o/r", Number: number, Secret_type: "slack_api_token"}
+	}
+	tests := []struct {
+		name      string
+		oldAlerts []SnapshotAlert
+		newAlerts []SnapshotAlert
+		want      []AlertChange
+	}{
+		{
+			name:      "no changes",
+			oldAlerts: []SnapshotAlert{alert(1, "open", "active")},
+			newAlerts: []SnapshotAlert{alert(1, "open", "active")},
+		},
+		{
+			name:      "new alert",
+			newAlerts: []SnapshotAlert{alert(1, "open", "unknown")},
+			want:      []AlertChange{change(changeNew, SnapshotAlert{}, alert(1, "open", "unknown"))},
+		},
+		{
+			name:      "newly active",
+			oldAlerts: []SnapshotAlert{alert(1, "open", "unknown")},
+			newAlerts: []SnapshotAlert{alert(1, "open", "active")},
+			want:      []AlertChange{change(changeNewlyActive, alert(1, "open", "unknown"), alert(1, "open", "active"))},
+		},
+		{
+			name:      "inactive without having been active",
+			oldAlerts: []SnapshotAlert{alert(1, "open", "unknown")},
+			newAlerts: []SnapshotAlert{alert(1, "open", "inactive")},
+		},
+		{
+			name:      "went inactive and resolved",
+			oldAlerts: []SnapshotAlert{alert(1, "open", "active")},
+			newAlerts: []SnapshotAlert{alert(1, "resolved", "inactive")},
+			want: []AlertChange{
+				change(changeWentInactive, alert(1, "open", "active"), alert(1, "resolved", "inactive")),
+				change(changeResolved, alert(1, "open", "active"), alert(1, "resolved", "inactive")),
+			},
+		},
+		{
+			name:      "removed",
+			oldAlerts: []SnapshotAlert{alert(1, "open", "active")},
+			want:      []AlertChange{change(changeRemoved, alert(1, "open", "active"), removed(1))},
+		},
+		{
+			name:      "sorted by repository and number",
+			oldAlerts: []SnapshotAlert{alert(3, "open", ""), {Repository: "a/b", Number: 9, State: "open"}},
+			newAlerts: []SnapshotAlert{alert(2, "open", ""), alert(3, "resolved", ""), alert(3, "resolved", "")},
+			want: []AlertChange{
+				change(changeRemoved, SnapshotAlert{Repository: "a/b", Number: 9, State: "open"}, SnapshotAlert{Repository: "a/b", Number: 9}),
+				change(changeNew, SnapshotAlert{}, alert(2, "open", "")),
+				change(changeResolved, alert(3, "open", ""), alert(3, "resolved", "")),
+			},
+		},
+	}
+	for _, test := range tests {
+		if got := diffSnapshotAlerts(test.oldAlerts, test.newAlerts); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: diffSnapshotAlerts() = %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestReadCSVReport(t *testing.T) {
+	tests := []struct {
+		name    string
+		report  string
+		want    []SnapshotAlert
+		wantErr string
+	}{
+		{
+			name:   "alerts report",
+			report: "Repository,ID,State,Secret Type,GitHub Validity,Resolution,URL\no/r,1,resolved,slack_api_token,inactive,revoked,https://github.com/o/r/security/secret-scanning/1\n",
+			want:   []SnapshotAlert{{Repository: "o/r", Number: 1, State: "resolved", Secret_type: "slack_api_token", Validity: "inactive", Resolution: "revoked", HTML_URL: "https://github.com/o/r/security/secret-scanning/1"}},
+		},
+		{
+			name:   "verification takes precedence over GitHub validity",
+			report: "ID,Repository,Verification,GitHub Validity\n2,o/r,active,unknown\n3,o/r,unknown,inactive\n",
+			want:   []SnapshotAlert{{Repository: "o/r", Number: 2, Validity: "active"}, {Repository: "o/r", Number: 3, Validity: "inactive"}},
+		},
+		{
+			name:   "header only",
+			report: "Repository,ID\n",
+		},
+		{
+			name:    "missing column",
+			report:  "Repository,State\no/r,open\n",
+			wantErr: `missing the "ID" column`,
+		},
+		{
+			name:    "invalid ID",
+			report:  "Repository,ID\no/r,one\n",
+			wantErr: `invalid alert ID "one"`,
+		},
+	}
+	for _, test := range tests {
+		got, err := readCSVReport(strings.NewReader(test.report))
+		if test.wantErr != "" {
+			if err == nil || err.Error() != test.wantErr {
+				t.Errorf("%s: readCSVReport() error = %v, want %q", test.name, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: readCSVReport() failed: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: readCSVReport() = %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSnapshots(t *testing.T) {
+	store := newTestStateStore(t)
+	sources := []alertSource{{requestPath: "orgs/o/secret-scanning/alerts?after=&per_page=100&state=open"}}
+	targets := []Target{{Scope: "organization", Name: "o"}}
+	take := func(alerts ...Alert) *Snapshot {
+		t.Helper()
+		snapshot, err := store.beginSnapshot("alerts", targets, sources, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = snapshot.add(alerts); err != nil {
+			t.Fatal(err)
+		}
+		if err = snapshot.finish(); err != nil {
+			t.Fatal(err)
+		}
+		return snapshot
+	}
+	alert := func(number int, state string) Alert {
+		return Alert{Number: number, State: state, Repository: Repository{Full_name: "o/r"}}
+	}
+	snapshotNumbers := func(snapshot Snapshot) (numbers []int) {
+		alerts, err := store.snapshotAlerts(snapshot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, alert := range alerts {
+			numbers = append(numbers, alert.Number)
+		}
+		return numbers
+	}
+
+	take(alert(1, "open"), alert(2, "open"))
+	// a --sync run only adds the alerts that changed to a copy of the previous snapshot:
+	syncOnly = true
+	take(alert(2, "resolved"), alert(3, "open"))
+	syncOnly = false
+	latest, err := store.latestTargetSnapshots(targets, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(latest) != 2 {
+		t.Fatalf("got %d snapshots, want 2", len(latest))
+	}
+	if got := snapshotNumbers(latest[0]); !reflect.DeepEqual(got, []int{1, 2, 3}) || latest[0].Alert_count != 3 {
+		t.Errorf("synced snapshot has alerts %v (count %d), want [1 2 3]", got, latest[0].Alert_count)
+	}
+	if query := latest[0].Query; !reflect.DeepEqual(query, []string{"orgs/o/secret-scanning/alerts?per_page=100&state=open"}) {
+		t.Errorf("snapshot query = %v, want the request without its cursor", query)
+	}
+
+	// a snapshot whose run stopped part way through can't be compared, and is removed by the next run:
+	stopped, err := store.beginSnapshot("alerts", targets, sources, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = stopped.add([]Alert{alert(4, "open")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = store.snapshot(stopped.Id); err == nil {
+		t.Errorf("incomplete snapshot %d can be compared", stopped.Id)
+	}
+
+	// snapshots of other queries aren't compared:
+	take(alert(1, "open"))
+	latest, err = store.latestSnapshots("alerts", []string{"orgs/o/secret-scanning/alerts?per_page=100&state=resolved"}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(latest) != 0 {
+		t.Errorf("got %d snapshots of another query, want none", len(latest))
+	}
+
+	// only the newest snapshots are kept:
+	keepSnapshots = 2
+	defer func() { keepSnapshots = 100 }()
+	newest := take(alert(5, "open"))
+	snapshots, err := store.snapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []uint64
+	for _, snapshot := range snapshots {
+		ids = append(ids, snapshot.Id)
+	}
+	if want := []uint64{newest.Id, newest.Id - 1}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("kept snapshots %v, want %v", ids, want)
+	}
+}
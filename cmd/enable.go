@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cli/go-gh/pkg/tableprinter"
+	"github.com/cli/go-gh/pkg/term"
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/spf13/cobra"
+)
+
+// the features that can be enabled, in the order they have to be enabled in:
+var enableableFeatures = []string{"advanced_security", "secret_scanning", "push_protection", "validity_checks"}
+
+var enableFeatures []string
+var repositoryFilter string
+
+func init() {
+	enableCmd.PersistentFlags().StringSliceVar(&enableFeatures, "features", []string{"secret_scanning", "push_protection"}, "Features to enable: "+strings.Join(enableableFeatures, ", "))
+	enableCmd.PersistentFlags().StringVar(&repositoryFilter, "filter", "", "Only enable features for repositories whose owner/name matches this regular expression")
+	enableCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Preview the repositories that would be changed without changing them")
+	enableCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "Skip the confirmation prompt")
+}
+
+var enableCmd = &cobra.Command{
+	Use:   "enable [repository...] [flags]",
+	Short: "Enable secret scanning and push protection for the repositories in an enterprise, organization, or repository",
+	Long:  "Enable secret scanning features for every repository in an enterprise, organization, or repository. Pass repository names to only change those repositories, e.g. \"gh secret-scanning enable -o my-org repo-a repo-b\". Changing the settings requires admin access to each repository.",
+	PreRunE: func(cmd *cobra.Command, args []string) (err error) {
+		for _, feature := range enableFeatures {
+			if !slices.Contains(enableableFeatures, feature) {
+				return fmt.Errorf("unknown feature %q, supported features are: %s", feature, strings.Join(enableableFeatures, ", "))
+			}
+		}
+		if repositoryFilter != "" {
+			if _, err = regexp.Compile(repositoryFilter); err != nil {
+				return fmt.Errorf("invalid --filter: %w", err)
+			}
+		}
+		if len(targetRepositories) > 0 && len(args) > 0 {
+			return errors.New("repository names can't be combined with --repository")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		return runEnable(cmd, args)
+	},
+}
+
+// EnableResult is the outcome of enabling features for a single repository.
+type EnableResult struct {
+	Repository string   `json:"repository"`
+	Features   []string `json:"features"`
+	Result     string   `json:"result"`
+	Error      string   `json:"error"`
+}
+
+type securityAndAnalysisUpdate struct {
+	Security_and_analysis SecurityAndAnalysis `json:"security_and_analysis"`
+}
+
+func runEnable(cmd *cobra.Command, args []string) (err error) {
+	// set the targets based on the flags that were used, without requiring secret scanning to be enabled:
+	targets, err := getTargets()
+	if err != nil {
+		return err
+	}
+
+	client, err := api.NewRESTClient(setOptions())
+	if err != nil {
+		return err
+	}
+	var repositories []RepositorySettings
+	if len(args) > 0 {
+		repositories, err = getRepositories(client, targets, args)
+	} else {
+		repositories, err = listTargetRepositories(client, targets)
+	}
+	if err != nil {
+		return err
+	}
+
+	// work out which of the requested features each repository is missing:
+	var filter *regexp.Regexp
+	if repositoryFilter != "" {
+		filter = regexp.MustCompile(repositoryFilter)
+	}
+	var pending []EnableResult
+	var results []EnableResult
+	for _, repository := range repositories {
+		if filter != nil && !filter.MatchString(repository.Full_name) {
+			continue
+		}
+		if repository.Archived {
+			results = append(results, EnableResult{Repository: repository.Full_name, Result: "skipped (archived)"})
+			continue
+		}
+		features := missingFeatures(newRepositoryCoverage(repository), enableFeatures)
+		if len(features) == 0 {
+			results = append(results, EnableResult{Repository: repository.Full_name, Result: "up to date"})
+			continue
+		}
+		pending = append(pending, EnableResult{Repository: repository.Full_name, Features: features})
+	}
+	if len(pending) == 0 {
+		fmt.Fprintln(logOutput(), Blue("No repositories need to be changed."))
+		return writeEnableResults(results)
+	}
+
+	// preview the repositories that will be changed:
+	fmt.Fprintln(logOutput(), Blue("The following "+strconv.Itoa(len(pending))+" repositories will be changed:"))
+	terminal := term.FromEnv()
+	termWidth, _, _ := terminal.Size()
+	t := tableprinter.New(logOutput(), terminal.IsTerminalOutput(), termWidth)
+	for _, header := range []string{"Repository", "Enable"} {
+		t.AddField(header, tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+	}
+	t.EndRow()
+	for _, change := range pending {
+		t.AddField(change.Repository, tableprinter.WithColor(Gray), tableprinter.WithTruncate(nil))
+		t.AddField(strings.Join(change.Features, ", "), tableprinter.WithColor(Gray), tableprinter.WithTruncate(nil))
+		t.EndRow()
+	}
+	if err = t.Render(); err != nil {
+		return fmt.Errorf("error rendering table: %v", err)
+	}
+
+	if dryRun {
+		fmt.Fprintln(logOutput(), Blue("Dry run: no repositories were changed."))
+		// the preview is on stderr with --json, so the repositories that would change are included in the JSON:
+		if jsonOutput() {
+			for _, change := range pending {
+				change.Result = "dry run"
+				results = append(results, change)
+			}
+			return writeEnableResults(results)
+		}
+		return nil
+	}
+	if !assumeYes && !confirm(Yellow("Enable "+strings.Join(enableFeatures, ", ")+" for "+strconv.Itoa(len(pending))+" repositories? (y/n)")) {
+		fmt.Fprintln(logOutput(), Blue("No repositories were changed."))
+		return nil
+	}
+
+	var errs []error
+	for _, change := range pending {
+		change.Result = "enabled"
+		if err := enableRepositoryFeatures(client, change.Repository, change.Features); err != nil {
+			change.Result, change.Error = "failed", err.Error()
+			errs = append(errs, fmt.Errorf("unable to enable %s for %s: %w", strings.Join(change.Features, ", "), change.Repository, err))
+		}
+		results = append(results, change)
+	}
+	if err = writeEnableResults(results); err != nil {
+		return err
+	}
+	fmt.Fprintln(logOutput(), Blue("Changed "+strconv.Itoa(len(pending)-len(errs))+" of "+strconv.Itoa(len(pending))+" repositories."))
+	return errors.Join(errs...)
+}
+
+func getRepositories(client *api.RESTClient, targets []Target, names []string) (repositories []RepositorySettings, err error) {
+	for _, name := range names {
+		// names are relative to the targeted organization, or include the owner:
+		if !strings.Contains(name, "/") {
+			if len(targets) != 1 || targets[0].Scope != "organization" {
+				return nil, fmt.Errorf("repository %q must follow the format 'owner/repository' unless a single organization is targeted", name)
+			}
+			name = targets[0].Name + "/" + name
+		}
+		var repository RepositorySettings
+		if _, _, err = callGitHubAPI(client, "repos/"+name, &repository, GET); err != nil {
+			return nil, fmt.Errorf("unable to get repository information for %s: %w", name, err)
+		}
+		repositories = append(repositories, repository)
+	}
+	return repositories, nil
+}
+
+func missingFeatures(coverage RepositoryCoverage, features []string) (missing []string) {
+	status := map[string]string{
+		"advanced_security": coverage.Advanced_security,
+		"secret_scanning":   coverage.Secret_scanning,
+		"push_protection":   coverage.Push_protection,
+		"validity_checks":   coverage.Validity_checks,
+	}
+	// keep the order features have to be enabled in, e.g. advanced security before secret scanning, and
+	// skip features that don't apply to the repository:
+	for _, feature := range enableableFeatures {
+		if slices.Contains(features, feature) && status[feature] != "enabled" && status[feature] != featureUnavailable {
+			missing = append(missing, feature)
+		}
+	}
+	return missing
+}
+
+func enableRepositoryFeatures(client *api.RESTClient, repository string, features []string) (err error) {
+	enabled := &FeatureStatus{Status: "enabled"}
+	var securityAndAnalysis SecurityAndAnalysis
+	for _, feature := range features {
+		switch feature {
+		case "advanced_security":
+			securityAndAnalysis.Advanced_security = enabled
+		case "secret_scanning":
+			securityAndAnalysis.Secret_scanning = enabled
+		case "push_protection":
+			securityAndAnalysis.Secret_scanning_push_protection = enabled
+		case "validity_checks":
+			securityAndAnalysis.Secret_scanning_validity_checks = enabled
+		}
+	}
+	// only send the security and analysis settings, so that nothing else about the repository changes:
+	body, err := json.Marshal(securityAndAnalysisUpdate{Security_and_analysis: securityAndAnalysis})
+	if err != nil {
+		return err
+	}
+	var updatedRepository RepositorySettings
+	_, _, err = callGitHubAPI(client, "repos/"+repository, &updatedRepository, PATCH, body)
+	return err
+}
+
+// writeEnableResults writes the results as JSON, or pretty prints them.
+func writeEnableResults(results []EnableResult) (err error) {
+	if jsonOutput() {
+		if ndjson {
+			return writeNDJSON(os.Stdout, results)
+		}
+		return writeJSON(os.Stdout, results)
+	}
+	if quiet || len(results) == 0 {
+		return nil
+	}
+	terminal := term.FromEnv()
+	termWidth, _, _ := terminal.Size()
+	t := tableprinter.New(terminal.Out(), terminal.IsTerminalOutput(), termWidth)
+	for _, header := range []string{"Repository", "Features", "Result", "Error"} {
+		t.AddField(header, tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+	}
+	t.EndRow()
+	for _, result := range results {
+		color := Gray
+		switch result.Result {
+		case "enabled":
+			color = Green
+		case "failed":
+			color = Red
+		}
+		t.AddField(result.Repository, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(strings.Join(result.Features, ", "), tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(result.Result, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(result.Error, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.EndRow()
+	}
+	if err = t.Render(); err != nil {
+		return fmt.Errorf("error rendering table: %v", err)
+	}
+	return nil
+}
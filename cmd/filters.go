@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/spf13/cobra"
+)
+
+var alertState string
+var alertResolutions []string
+var alertSort string
+var alertDirection string
+var alertValidities []string
+var publiclyLeaked bool
+var multiRepo bool
+
+// alertFilter maps a flag onto a query parameter of the list alerts endpoints:
+type alertFilter struct {
+	flag    string
+	param   string
+	allowed []string
+	// the first GHES release that supports the parameter, GitHub.com supports all of them:
+	minimumGHESVersion string
+	value              func() []string
+}
+
+var alertFilters = []alertFilter{
+	{flag: "state", param: "state", allowed: []string{"open", "resolved"}, value: func() []string { return optionalValue(alertState) }},
+	{flag: "resolution", param: "resolution", allowed: []string{"false_positive", "wont_fix", "revoked", "used_in_tests", "pattern_edited", "pattern_deleted"}, value: func() []string { return alertResolutions }},
+	{flag: "sort", param: "sort", allowed: []string{"created", "updated"}, value: func() []string { return optionalValue(alertSort) }},
+	{flag: "direction", param: "direction", allowed: []string{"asc", "desc"}, value: func() []string { return optionalValue(alertDirection) }},
+	{flag: "validity", param: "validity", allowed: []string{"active", "inactive", "unknown"}, minimumGHESVersion: "3.12", value: func() []string { return alertValidities }},
+	{flag: "publicly-leaked", param: "is_publicly_leaked", minimumGHESVersion: "3.16", value: func() []string { return trueValue(publiclyLeaked) }},
+	{flag: "multi-repo", param: "is_multi_repo", minimumGHESVersion: "3.17", value: func() []string { return trueValue(multiRepo) }},
+}
+
+func addAlertFilterFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&alertState, "state", "", "Filter alerts by state: open or resolved")
+	cmd.PersistentFlags().StringSliceVar(&alertResolutions, "resolution", nil, "Filter resolved alerts by resolution: false_positive, wont_fix, revoked, used_in_tests, pattern_edited or pattern_deleted")
+	cmd.PersistentFlags().StringVar(&alertSort, "sort", "", "Sort alerts by created or updated time")
+	cmd.PersistentFlags().StringVar(&alertDirection, "direction", "", "Sort direction: asc or desc")
+	cmd.PersistentFlags().StringSliceVar(&alertValidities, "validity", nil, "Filter alerts by the validity reported by GitHub: active, inactive or unknown (GHES 3.12+)")
+	cmd.PersistentFlags().BoolVar(&publiclyLeaked, "publicly-leaked", false, "Only include alerts for secrets that were leaked publicly (GHES 3.16+)")
+	cmd.PersistentFlags().BoolVar(&multiRepo, "multi-repo", false, "Only include alerts for secrets found in multiple repositories (GHES 3.17+)")
+}
+
+func optionalValue(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
+func trueValue(set bool) []string {
+	// boolean filters are only sent when enabled, false would still mean "don't filter":
+	if !set {
+		return nil
+	}
+	return []string{"true"}
+}
+
+// setAlertFilterParameters adds the query parameters for the filters that were specified, after checking
+// their values and that the server supports them.
+func setAlertFilterParameters(client *api.RESTClient, values url.Values) (err error) {
+	var serverVersion string
+	for _, filter := range alertFilters {
+		filterValues := filter.value()
+		if len(filterValues) == 0 {
+			continue
+		}
+		for _, value := range filterValues {
+			if filter.allowed != nil && !slices.Contains(filter.allowed, value) {
+				return fmt.Errorf("invalid --%s value %q, must be one of: %s", filter.flag, value, strings.Join(filter.allowed, ", "))
+			}
+		}
+		if filter.minimumGHESVersion != "" && isGHES() {
+			if serverVersion == "" {
+				if serverVersion, err = getGHESVersion(client); err != nil {
+					return err
+				}
+			}
+			if compareVersions(serverVersion, filter.minimumGHESVersion) < 0 {
+				return fmt.Errorf("--%s requires GitHub Enterprise Server %s or later, %s is running %s", filter.flag, filter.minimumGHESVersion, host, serverVersion)
+			}
+		}
+		values.Set(filter.param, strings.Join(filterValues, ","))
+	}
+	return nil
+}
+
+func isGHES() bool {
+	// GitHub.com and GHE.com data residency hosts are always up to date:
+	return host != "github.com" && !strings.HasSuffix(host, ".ghe.com")
+}
+
+type serverMeta struct {
+	Installed_version string `json:"installed_version"`
+}
+
+func getGHESVersion(client *api.RESTClient) (version string, err error) {
+	var meta serverMeta
+	if _, _, err = callGitHubAPI(client, "meta", &meta, GET); err != nil {
+		return "", fmt.Errorf("unable to get the GitHub Enterprise Server version: %w", err)
+	}
+	if meta.Installed_version == "" {
+		return "", fmt.Errorf("unable to get the GitHub Enterprise Server version of %s", host)
+	}
+	return meta.Installed_version, nil
+}
+
+func compareVersions(a string, b string) int {
+	// compare dotted release numbers, e.g. "3.12.4" and "3.12", numerically:
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+	for i := 0; i < max(len(aParts), len(bParts)); i++ {
+		var aPart, bPart int
+		if i < len(aParts) {
+			aPart, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			bPart, _ = strconv.Atoi(bParts[i])
+		}
+		if aPart != bPart {
+			if aPart < bPart {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
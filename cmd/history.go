@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/cli/go-gh/pkg/tableprinter"
+	"github.com/cli/go-gh/pkg/term"
+	"github.com/spf13/cobra"
+)
+
+var historyAlert int
+
+func init() {
+	historyCmd.PersistentFlags().IntVar(&historyAlert, "alert", 0, "Show the verification timeline of a single alert, by number (requires a single --repository)")
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history [flags]",
+	Short: "Show the recorded history of alerts for an enterprise, organization, or repository",
+	Long:  "Show when each alert was first seen, first verified active and revoked, as recorded by earlier alerts and verify runs, and the mean time to revoke.",
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		return runHistory(cmd, args)
+	},
+}
+
+func runHistory(cmd *cobra.Command, args []string) (err error) {
+	if noState {
+		return errors.New("the history is not available with --no-state")
+	}
+	targets, err := getTargets()
+	if err != nil {
+		return err
+	}
+	if historyAlert != 0 && (len(targets) != 1 || targets[0].Scope != "repository") {
+		return errors.New("--alert requires a single --repository")
+	}
+	store, err := openStateStore()
+	if err != nil {
+		return err
+	}
+	defer store.close()
+	records, err := store.records(targets)
+	if err != nil {
+		return err
+	}
+	if historyAlert != 0 {
+		for _, record := range records {
+			if record.Number == historyAlert {
+				if jsonOutput() {
+					return writeJSON(os.Stdout, []AlertRecord{record})
+				}
+				return printVerificationTimeline(record)
+			}
+		}
+		return fmt.Errorf("no history recorded for alert %d in %s", historyAlert, targets[0].Name)
+	}
+
+	records = sortAlertRecords(records)
+	if jsonOutput() {
+		if ndjson {
+			return writeNDJSON(os.Stdout, records)
+		}
+		return writeJSON(os.Stdout, records)
+	}
+	if err = printHistoryTable(records); err != nil {
+		return err
+	}
+	fmt.Println(Blue(meanTimeToRevoke(records)))
+	return nil
+}
+
+func sortAlertRecords(records []AlertRecord) []AlertRecord {
+	// sort records by repo name and then alert number, like alerts:
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Repository == records[j].Repository {
+			return records[i].Number < records[j].Number
+		}
+		return records[i].Repository < records[j].Repository
+	})
+	return records
+}
+
+func meanTimeToRevoke(records []AlertRecord) string {
+	var total time.Duration
+	count := 0
+	for _, record := range records {
+		if timeToRevoke, ok := record.timeToRevoke(); ok {
+			total += timeToRevoke
+			count++
+		}
+	}
+	if count == 0 {
+		return "No revoked secrets recorded."
+	}
+	return "Mean time to revoke: " + formatDuration(total/time.Duration(count)) + " across " + strconv.Itoa(count) + " revoked secrets."
+}
+
+func formatDuration(d time.Duration) string {
+	// e.g. "3d 4h 5m", since time.Duration only counts in hours:
+	d = d.Round(time.Minute)
+	days := d / (24 * time.Hour)
+	hours := (d % (24 * time.Hour)) / time.Hour
+	minutes := (d % time.Hour) / time.Minute
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
+	}
+	if hours > 0 {
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Local().Format(time.DateTime)
+}
+
+func printHistoryTable(records []AlertRecord) (err error) {
+	if len(records) == 0 {
+		fmt.Println(Blue("No history recorded yet, run the alerts or verify subcommand first."))
+		return nil
+	}
+	terminal := term.FromEnv()
+	termWidth, _, _ := terminal.Size()
+	t := tableprinter.New(terminal.Out(), terminal.IsTerminalOutput(), termWidth)
+	for _, header := range []string{"Repository", "ID", "State", "Secret Type", "First Seen", "First Active", "Revoked", "Time To Revoke", "Last Verification"} {
+		t.AddField(header, tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+	}
+	t.EndRow()
+	for _, record := range records {
+		var lastOutcome Outcome
+		if last := record.lastVerification(); last != nil {
+			lastOutcome = last.Outcome
+		}
+		var timeToRevoke string
+		if duration, ok := record.timeToRevoke(); ok {
+			timeToRevoke = formatDuration(duration)
+		}
+		color := outcomeColor(lastOutcome)
+		t.AddField(record.Repository, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(strconv.Itoa(record.Number), tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(record.State, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(record.Secret_type, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(formatTime(&record.First_seen), tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(formatTime(record.First_active), tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(formatTime(record.Revoked_at), tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(timeToRevoke, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(string(lastOutcome), tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.EndRow()
+	}
+	if err = t.Render(); err != nil {
+		return fmt.Errorf("error rendering table: %v", err)
+	}
+	return nil
+}
+
+func printVerificationTimeline(record AlertRecord) (err error) {
+	fmt.Println(Blue("Alert " + strconv.Itoa(record.Number) + " in " + record.Repository + " (" + record.Secret_type + "), first seen " + formatTime(&record.First_seen) + ":"))
+	terminal := term.FromEnv()
+	termWidth, _, _ := terminal.Size()
+	t := tableprinter.New(terminal.Out(), terminal.IsTerminalOutput(), termWidth)
+	for _, header := range []string{"Timestamp", "Outcome", "Status Code", "Endpoint", "Reason"} {
+		t.AddField(header, tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+	}
+	t.EndRow()
+	for _, verification := range record.Verifications {
+		color := outcomeColor(verification.Outcome)
+		t.AddField(formatTime(&verification.Timestamp), tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(string(verification.Outcome), tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(verification.Status_code, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(verification.Endpoint, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.AddField(verification.Reason, tableprinter.WithColor(color), tableprinter.WithTruncate(nil))
+		t.EndRow()
+	}
+	if err = t.Render(); err != nil {
+		return fmt.Errorf("error rendering table: %v", err)
+	}
+	if record.Revoked_at != nil {
+		fmt.Println(Blue("Revoked " + formatTime(record.Revoked_at) + "."))
+	}
+	return nil
+}
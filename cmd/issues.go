@@ -0,0 +1,468 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	bolt "go.etcd.io/bbolt"
+)
+
+// issueMarker is a hidden token in the issue footer that identifies issues created by this extension. The
+// footer is appended to every issue body, including those rendered from a custom template:
+const issueMarker = "<!-- gh-secret-scanning:active-secrets -->"
+const issueFooter = "\n\n" + issueMarker + "\n<sub>This issue is managed by gh-secret-scanning.</sub>\n"
+
+// issueLabel is added to every issue created by this extension, so that a repository's open issues can be
+// listed by label instead of searched for, which is rate limited and only eventually consistent:
+const issueLabel = "gh-secret-scanning"
+
+var issuesBucket = []byte("issues")
+
+// defaultIssueTemplate defines the "title" and "body" templates, either of which can be overridden
+// by an --issue-template file:
+const defaultIssueTemplate = `{{define "title"}}IMMEDIATE ACTION REQUIRED: Active Secrets Detected{{end}}
+{{- define "body"}}**Please promptly revoke the following secrets and confirm in the provider's logs that they have not been used maliciously:**
+
+| Alert ID | Secret Type | Alert Link |
+| --- | --- | --- |
+{{range .Alerts}}| {{.Number}} | {{.Secret_type}} | [Link]({{.HTML_URL}}) |
+{{end}}
+{{- if .UnverifiedAlerts}}
+**The following secrets could not be verified and should be reviewed manually:**
+
+| Alert ID | Secret Type | Reason | Alert Link |
+| --- | --- | --- | --- |
+{{range .UnverifiedAlerts}}| {{.Number}} | {{.Secret_type}} | {{.Validity_reason}} | [Link]({{.HTML_URL}}) |
+{{end}}
+{{- end}}
+{{- if .Owners}}
+**Code owners:** {{range $i, $owner := .Owners}}{{if $i}} {{end}}{{$owner}}{{end}}
+{{end}}
+_Last verified {{.VerifiedAt}}._{{end}}`
+
+// IssueTemplateData is passed to the issue title and body templates.
+type IssueTemplateData struct {
+	Host             string
+	Repository       string
+	Alerts           []Alert
+	UnverifiedAlerts []Alert
+	Owners           []string
+	VerifiedAt       string
+}
+
+var issueTemplateFile string
+var issueLabels []string
+var issueAssignees []string
+var issueMilestone string
+var issueProject string
+var notifyCodeOwners bool
+var assignCodeOwners bool
+var issueTemplate *template.Template
+
+func loadIssueTemplate(path string) (err error) {
+	issueTemplate, err = template.New("issue").Option("missingkey=error").Parse(defaultIssueTemplate)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read issue template: %w", err)
+	}
+	// definitions in the file replace the default "title" and/or "body":
+	if issueTemplate, err = issueTemplate.Parse(string(data)); err != nil {
+		return fmt.Errorf("unable to parse issue template %s: %w", path, err)
+	}
+	return nil
+}
+
+func renderIssue(repo string, alerts []Alert, owners []string) (title string, body string, err error) {
+	data := IssueTemplateData{Host: host, Repository: repo, Owners: owners, VerifiedAt: time.Now().UTC().Format(time.RFC3339)}
+	for _, alert := range alerts {
+		if alert.Validity_outcome == OutcomeActive {
+			data.Alerts = append(data.Alerts, alert)
+		} else if alert.Validity_outcome == OutcomeUnknown {
+			data.UnverifiedAlerts = append(data.UnverifiedAlerts, alert)
+		}
+	}
+	var titleBuffer, bodyBuffer bytes.Buffer
+	if err = issueTemplate.ExecuteTemplate(&titleBuffer, "title", data); err != nil {
+		return "", "", err
+	}
+	if err = issueTemplate.ExecuteTemplate(&bodyBuffer, "body", data); err != nil {
+		return "", "", err
+	}
+	return strings.TrimSpace(titleBuffer.String()), bodyBuffer.String() + issueFooter, nil
+}
+
+// Issue is the subset of the issues API response used to create, update and close issues.
+type Issue struct {
+	Number   int    `json:"number"`
+	Node_id  string `json:"node_id"`
+	HTML_URL string `json:"html_url"`
+	Title    string `json:"title"`
+	Body     string `json:"body"`
+}
+
+type issueRequest struct {
+	Title        string   `json:"title,omitempty"`
+	Body         string   `json:"body,omitempty"`
+	Labels       []string `json:"labels,omitempty"`
+	Assignees    []string `json:"assignees,omitempty"`
+	Milestone    int      `json:"milestone,omitempty"`
+	State        string   `json:"state,omitempty"`
+	State_reason string   `json:"state_reason,omitempty"`
+}
+
+type Milestone struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+}
+
+// coversAllOpenAlerts reports whether a run fetched every open alert of the repositories it scanned, so
+// that a repository without active secrets really has none left. Filters and the limit leave alerts out.
+func coversAllOpenAlerts(fetched int) bool {
+	if provider != "" || (alertState != "" && alertState != "open") || len(alertResolutions) > 0 || len(alertValidities) > 0 || publiclyLeaked || multiRepo {
+		return false
+	}
+	return fetched < limit
+}
+
+func createIssuesForValidAlerts(client *api.RESTClient, store *stateStore, alerts []Alert, scannedRepos []string, complete bool) (err error) {
+	fmt.Fprintln(logOutput(), Blue("Creating issues for valid alerts..."))
+	created_count, updated_count, closed_count := 0, 0, 0
+	var errs []error
+	alertsByRepo := make(map[string][]Alert)
+	for _, alert := range alerts {
+		alertsByRepo[alert.Repository.Full_name] = append(alertsByRepo[alert.Repository.Full_name], alert)
+	}
+	sort.Strings(scannedRepos)
+	for _, repo := range scannedRepos {
+		alerts := alertsByRepo[repo]
+		// check if there is at least one confirmed valid secret alert
+		hasValidAlert, hasUnverifiedAlert := false, false
+		for _, alert := range alerts {
+			switch alert.Validity_outcome {
+			case OutcomeActive:
+				hasValidAlert = true
+			case OutcomeUnknown:
+				hasUnverifiedAlert = true
+			}
+		}
+		// secrets that couldn't be checked may still be active, so the issue is neither created nor closed:
+		if !hasValidAlert && hasUnverifiedAlert {
+			continue
+		}
+		// a run that didn't fetch every open alert can't tell that none are active, so it leaves the issue
+		// alone:
+		if !hasValidAlert && !complete {
+			continue
+		}
+		// only repositories that had an issue opened by a previous run can have one to close. Without the
+		// local history, every scanned repository is checked:
+		if !hasValidAlert && store != nil {
+			hasIssue, err := store.hasIssue(repo)
+			if err != nil {
+				return err
+			}
+			if !hasIssue {
+				continue
+			}
+		}
+		// reuse the issue opened by a previous run, if there is one:
+		existing, found, err := findOpenIssue(client, repo)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to list issues in %s: %w", repo, err))
+			continue
+		}
+		if !hasValidAlert {
+			if !found {
+				// the issue was closed by someone else:
+				if err = store.forgetIssue(repo); err != nil {
+					return err
+				}
+				continue
+			}
+			// no active secrets remain, so the issue from a previous run can be closed:
+			comment := "No active secrets remain as of " + time.Now().UTC().Format(time.RFC3339) + "."
+			if err = closeIssue(client, repo, existing.Number, comment); err != nil {
+				errs = append(errs, fmt.Errorf("unable to close issue #%d in %s: %w", existing.Number, repo, err))
+				continue
+			}
+			if err = store.forgetIssue(repo); err != nil {
+				return err
+			}
+			closed_count++
+			fmt.Fprintln(logOutput(), "Closed issue #"+strconv.Itoa(existing.Number)+" in "+repo+": "+existing.HTML_URL)
+			continue
+		}
+
+		var owners []string
+		if notifyCodeOwners {
+			if owners, err = codeOwnersForRepo(client, repo, alerts); err != nil {
+				// the issue is still worth creating without owners:
+				fmt.Fprintln(logOutput(), "WARNING: Unable to determine code owners for "+repo+": "+err.Error())
+			}
+		}
+		request, err := newIssueRequest(client, repo, alerts, owners)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to prepare issue for %s: %w", repo, err))
+			continue
+		}
+		var issue Issue
+		if found {
+			issue, err = updateIssue(client, repo, existing.Number, request)
+		} else {
+			issue, err = createIssue(client, repo, request)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to create or update issue in %s: %w", repo, err))
+			continue
+		}
+		if err = store.recordIssue(repo, issue.Number); err != nil {
+			return err
+		}
+		if found {
+			updated_count++
+			fmt.Fprintln(logOutput(), "Updated issue #"+strconv.Itoa(issue.Number)+" in "+repo+": "+issue.HTML_URL)
+		} else {
+			created_count++
+			fmt.Fprintln(logOutput(), "Created issue #"+strconv.Itoa(issue.Number)+" in "+repo+": "+issue.HTML_URL)
+		}
+		if issueProject != "" {
+			if err = addIssueToProject(repo, issueProject, issue); err != nil {
+				errs = append(errs, fmt.Errorf("unable to add issue #%d in %s to project %q: %w", issue.Number, repo, issueProject, err))
+			}
+		}
+	}
+	fmt.Fprintln(logOutput(), Blue("Created "+strconv.Itoa(created_count)+", updated "+strconv.Itoa(updated_count)+" and closed "+strconv.Itoa(closed_count)+" issue(s)."))
+	return errors.Join(errs...)
+}
+
+func newIssueRequest(client *api.RESTClient, repo string, alerts []Alert, owners []string) (request issueRequest, err error) {
+	request.Title, request.Body, err = renderIssue(repo, alerts, owners)
+	if err != nil {
+		return request, err
+	}
+	request.Labels = append([]string{issueLabel}, issueLabels...)
+	request.Assignees = append(slices.Clone(issueAssignees), codeOwnerAssignees(owners)...)
+	if issueMilestone != "" {
+		if request.Milestone, err = findMilestone(client, repo, issueMilestone); err != nil {
+			return request, err
+		}
+	}
+	return request, nil
+}
+
+func codeOwnersForRepo(client *api.RESTClient, repo string, alerts []Alert) (owners []string, err error) {
+	// locations are only fetched for all alerts with --locations or --sarif, so look them up for the active ones here:
+	var activeAlerts []Alert
+	for _, alert := range alerts {
+		if alert.Validity_outcome == OutcomeActive {
+			activeAlerts = append(activeAlerts, alert)
+		}
+	}
+	if !fetchLocations() {
+		if err = addLocationsToAlerts(context.Background(), client, activeAlerts); err != nil {
+			return nil, err
+		}
+	}
+	rules, err := fetchCodeOwners(client, repo)
+	if err != nil {
+		return nil, err
+	}
+	return codeOwnersForAlerts(rules, activeAlerts), nil
+}
+
+func codeOwnerAssignees(owners []string) []string {
+	if !assignCodeOwners {
+		return nil
+	}
+	return assignableOwners(owners)
+}
+
+func findOpenIssue(client *api.RESTClient, repo string) (issue Issue, found bool, err error) {
+	query := url.Values{}
+	query.Set("labels", issueLabel)
+	query.Set("state", "open")
+	query.Set("per_page", "100")
+	var issues []Issue
+	if _, _, err = callGitHubAPI(client, "repos/"+repo+"/issues?"+query.Encode(), &issues, GET); err != nil {
+		return issue, false, err
+	}
+	// only issues carrying the marker were opened by this extension, anyone can add the label:
+	for _, issue := range issues {
+		if strings.Contains(issue.Body, issueMarker) {
+			return issue, true, nil
+		}
+	}
+	return issue, false, nil
+}
+
+func issueKey(repo string) []byte {
+	return []byte(host + "/" + repo)
+}
+
+// recordIssue remembers that a repository has an open issue, so that later runs know to close it once no
+// active secrets remain.
+func (s *stateStore) recordIssue(repo string, number int) error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(issuesBucket).Put(issueKey(repo), []byte(strconv.Itoa(number)))
+	})
+}
+
+func (s *stateStore) forgetIssue(repo string) error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(issuesBucket).Delete(issueKey(repo))
+	})
+}
+
+func (s *stateStore) hasIssue(repo string) (found bool, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		found = tx.Bucket(issuesBucket).Get(issueKey(repo)) != nil
+		return nil
+	})
+	return found, err
+}
+
+// issueRepos returns the repositories within the targets that have an open issue recorded.
+func (s *stateStore) issueRepos(targets []Target) (repos []string, err error) {
+	if s == nil {
+		return nil, nil
+	}
+	prefix := []byte(host + "/")
+	err = s.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(issuesBucket).Cursor()
+		for key, _ := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, _ = cursor.Next() {
+			repo := string(bytes.TrimPrefix(key, prefix))
+			if repositoryMatchesTargets(repo, targets) {
+				repos = append(repos, repo)
+			}
+		}
+		return nil
+	})
+	return repos, err
+}
+
+func createIssue(client *api.RESTClient, repo string, request issueRequest) (issue Issue, err error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return issue, err
+	}
+	_, _, err = callGitHubAPI(client, "repos/"+repo+"/issues", &issue, POST, body)
+	return issue, err
+}
+
+func updateIssue(client *api.RESTClient, repo string, number int, request issueRequest) (issue Issue, err error) {
+	// labels and assignees are added to the ones already on the issue, rather than replacing them:
+	labels, assignees := request.Labels, request.Assignees
+	request.Labels, request.Assignees = nil, nil
+	body, err := json.Marshal(request)
+	if err != nil {
+		return issue, err
+	}
+	issuePath := "repos/" + repo + "/issues/" + strconv.Itoa(number)
+	if _, _, err = callGitHubAPI(client, issuePath, &issue, PATCH, body); err != nil {
+		return issue, err
+	}
+	if len(labels) > 0 {
+		body, _ := json.Marshal(map[string][]string{"labels": labels})
+		var response []interface{}
+		if _, _, err = callGitHubAPI(client, issuePath+"/labels", &response, POST, body); err != nil {
+			return issue, err
+		}
+	}
+	if len(assignees) > 0 {
+		body, _ := json.Marshal(map[string][]string{"assignees": assignees})
+		var response Issue
+		if _, _, err = callGitHubAPI(client, issuePath+"/assignees", &response, POST, body); err != nil {
+			return issue, err
+		}
+	}
+	return issue, nil
+}
+
+func closeIssue(client *api.RESTClient, repo string, number int, comment string) (err error) {
+	issuePath := "repos/" + repo + "/issues/" + strconv.Itoa(number)
+	body, _ := json.Marshal(map[string]string{"body": comment})
+	var response interface{}
+	if _, _, err = callGitHubAPI(client, issuePath+"/comments", &response, POST, body); err != nil {
+		return err
+	}
+	body, _ = json.Marshal(issueRequest{State: "closed", State_reason: "completed"})
+	var issue Issue
+	_, _, err = callGitHubAPI(client, issuePath, &issue, PATCH, body)
+	return err
+}
+
+func findMilestone(client *api.RESTClient, repo string, title string) (number int, err error) {
+	// the API takes a milestone number, so look it up by title among the open milestones:
+	var milestones []Milestone
+	if _, _, err = callGitHubAPI(client, "repos/"+repo+"/milestones?state=open&per_page=100", &milestones, GET); err != nil {
+		return 0, err
+	}
+	for _, milestone := range milestones {
+		if strings.EqualFold(milestone.Title, title) {
+			return milestone.Number, nil
+		}
+	}
+	return 0, fmt.Errorf("milestone %q not found in %s", title, repo)
+}
+
+func addIssueToProject(repo string, title string, issue Issue) (err error) {
+	// projects are only available through the GraphQL API:
+	client, err := api.NewGraphQLClient(setOptions())
+	if err != nil {
+		return err
+	}
+	owner := strings.Split(repo, "/")[0]
+	var projects struct {
+		RepositoryOwner struct {
+			ProjectsV2 struct {
+				Nodes []struct {
+					Id    string
+					Title string
+				}
+			}
+		}
+	}
+	query := `query($login: String!, $title: String!) {
+		repositoryOwner(login: $login) {
+			... on ProjectV2Owner { projectsV2(first: 20, query: $title) { nodes { id title } } }
+		}
+	}`
+	if err = client.Do(query, map[string]interface{}{"login": owner, "title": title}, &projects); err != nil {
+		return err
+	}
+	for _, project := range projects.RepositoryOwner.ProjectsV2.Nodes {
+		if !strings.EqualFold(project.Title, title) {
+			continue
+		}
+		mutation := `mutation($project: ID!, $content: ID!) {
+			addProjectV2ItemById(input: {projectId: $project, contentId: $content}) { item { id } }
+		}`
+		var response interface{}
+		return client.Do(mutation, map[string]interface{}{"project": project.Id, "content": issue.Node_id}, &response)
+	}
+	return fmt.Errorf("project not found for %s", owner)
+}
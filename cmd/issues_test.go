@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// fakeIssues is an in-memory issues API for a single repository.
+type fakeIssues struct {
+	mutex    sync.Mutex
+	issues   []Issue
+	states   map[int]string
+	labels   map[int][]string
+	comments map[int][]string
+}
+
+func (f *fakeIssues) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, "/api/v3/repos/o/r/issues")
+	var request map[string]interface{}
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&request)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodGet && path == "":
+		if r.URL.Query().Get("labels") != issueLabel || r.URL.Query().Get("state") != "open" {
+			http.Error(w, "unexpected query "+r.URL.RawQuery, http.StatusBadRequest)
+			return
+		}
+		open := []Issue{}
+		for _, issue := range f.issues {
+			if f.states[issue.Number] == "open" && slices.Contains(f.labels[issue.Number], issueLabel) {
+				open = append(open, issue)
+			}
+		}
+		json.NewEncoder(w).Encode(open)
+	case r.Method == http.MethodPost && path == "":
+		issue := Issue{Number: len(f.issues) + 1, Title: request["title"].(string), Body: request["body"].(string)}
+		for _, label := range request["labels"].([]interface{}) {
+			f.labels[issue.Number] = append(f.labels[issue.Number], label.(string))
+		}
+		f.states[issue.Number] = "open"
+		f.issues = append(f.issues, issue)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(issue)
+	default:
+		number, rest, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+		n, err := strconv.Atoi(number)
+		if err != nil || n < 1 || n > len(f.issues) {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		issue := &f.issues[n-1]
+		switch {
+		case r.Method == http.MethodPatch && rest == "":
+			if title, ok := request["title"].(string); ok {
+				issue.Title = title
+			}
+			if body, ok := request["body"].(string); ok {
+				issue.Body = body
+			}
+			if state, ok := request["state"].(string); ok {
+				f.states[n] = state
+			}
+			json.NewEncoder(w).Encode(issue)
+		case r.Method == http.MethodPost && rest == "labels":
+			for _, label := range request["labels"].([]interface{}) {
+				f.labels[n] = append(f.labels[n], label.(string))
+			}
+			json.NewEncoder(w).Encode([]interface{}{})
+		case r.Method == http.MethodPost && rest == "comments":
+			f.comments[n] = append(f.comments[n], request["body"].(string))
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]interface{}{})
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}
+}
+
+func newTestRESTClient(t *testing.T, handler http.Handler) *api.RESTClient {
+	t.Helper()
+	server := httptest.NewTLSServer(handler)
+	t.Cleanup(server.Close)
+	client, err := api.NewRESTClient(api.ClientOptions{
+		Host:      strings.TrimPrefix(server.URL, "https://"),
+		AuthToken: "token",
+		Transport: server.Client().Transport,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func newTestStateStore(t *testing.T) *stateStore {
+	t.Helper()
+	stateFile = filepath.Join(t.TempDir(), "state.db")
+	t.Cleanup(func() { stateFile = "" })
+	store, err := openStateStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.close() })
+	return store
+}
+
+func TestCreateIssuesForValidAlerts(t *testing.T) {
+	if err := loadIssueTemplate(""); err != nil {
+		t.Fatal(err)
+	}
+	issues := &fakeIssues{states: map[int]string{}, labels: map[int][]string{}, comments: map[int][]string{}}
+	client := newTestRESTClient(t, issues)
+	store := newTestStateStore(t)
+	repos := []string{"o/r"}
+	withOutcome := func(outcome Outcome, numbers ...int) (alerts []Alert) {
+		for _, number := range numbers {
+			alerts = append(alerts, Alert{
+				Number:           number,
+				Secret_type:      "slack_api_token",
+				HTML_URL:         "https://github.com/o/r/security/secret-scanning/" + strconv.Itoa(number),
+				Repository:       Repository{Full_name: "o/r"},
+				Validity_outcome: outcome,
+			})
+		}
+		return alerts
+	}
+	active := func(numbers ...int) []Alert { return withOutcome(OutcomeActive, numbers...) }
+
+	steps := []struct {
+		name       string
+		alerts     []Alert
+		complete   bool
+		wantIssues int
+		wantState  string
+		wantBody   []string
+		wantRecord bool
+	}{
+		{name: "creates an issue", alerts: active(1), complete: true, wantIssues: 1, wantState: "open", wantBody: []string{"secret-scanning/1)", issueMarker}, wantRecord: true},
+		{name: "updates the open issue", alerts: active(1, 2), complete: true, wantIssues: 1, wantState: "open", wantBody: []string{"secret-scanning/1)", "secret-scanning/2)"}, wantRecord: true},
+		{name: "leaves the issue open while secrets can't be checked", alerts: withOutcome(OutcomeUnknown, 1, 2), complete: true, wantIssues: 1, wantState: "open", wantRecord: true},
+		{name: "leaves the issue open after a partial run", alerts: nil, complete: false, wantIssues: 1, wantState: "open", wantRecord: true},
+		{name: "closes the issue once no secrets are active", alerts: nil, complete: true, wantIssues: 1, wantState: "closed", wantRecord: false},
+		{name: "creates a new issue once secrets are active again", alerts: active(3), complete: true, wantIssues: 2, wantState: "open", wantBody: []string{"secret-scanning/3)"}, wantRecord: true},
+	}
+	for _, step := range steps {
+		if err := createIssuesForValidAlerts(client, store, step.alerts, repos, step.complete); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if len(issues.issues) != step.wantIssues {
+			t.Fatalf("%s: got %d issues, want %d", step.name, len(issues.issues), step.wantIssues)
+		}
+		latest := issues.issues[len(issues.issues)-1]
+		if state := issues.states[latest.Number]; state != step.wantState {
+			t.Errorf("%s: issue #%d is %s, want %s", step.name, latest.Number, state, step.wantState)
+		}
+		for _, want := range step.wantBody {
+			if !strings.Contains(latest.Body, want) {
+				t.Errorf("%s: issue body doesn't contain %q:\n%s", step.name, want, latest.Body)
+			}
+		}
+		recorded, err := store.hasIssue("o/r")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if recorded != step.wantRecord {
+			t.Errorf("%s: recorded issue is %t, want %t", step.name, recorded, step.wantRecord)
+		}
+	}
+	if len(issues.comments[1]) != 1 {
+		t.Errorf("got %d comments on the closed issue, want 1", len(issues.comments[1]))
+	}
+}
+
+func TestIssueRepos(t *testing.T) {
+	store := newTestStateStore(t)
+	for _, repo := range []string{"o/a", "o/b", "other/c"} {
+		if err := store.recordIssue(repo, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name    string
+		targets []Target
+		want    []string
+	}{
+		{"organization", []Target{{Scope: "organization", Name: "o"}}, []string{"o/a", "o/b"}},
+		{"repository", []Target{{Scope: "repository", Name: "other/c"}}, []string{"other/c"}},
+		{"enterprise", []Target{{Scope: "enterprise", Name: "e"}}, []string{"o/a", "o/b", "other/c"}},
+		{"no issues", []Target{{Scope: "organization", Name: "none"}}, nil},
+	}
+	for _, test := range tests {
+		got, err := store.issueRepos(test.targets)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s: issueRepos() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// Location is where a secret was found, as returned by the list locations endpoint. The details that
+// are set depend on the type: commit and wiki_commit locations have a path and line range, the other
+// types (issue_*, pull_request_*, discussion_*) only have an API URL.
+type Location struct {
+	Type    string          `json:"type"`
+	Details LocationDetails `json:"details"`
+}
+
+type LocationDetails struct {
+	Path                            string `json:"path,omitempty"`
+	Start_line                      int    `json:"start_line,omitempty"`
+	End_line                        int    `json:"end_line,omitempty"`
+	Start_column                    int    `json:"start_column,omitempty"`
+	End_column                      int    `json:"end_column,omitempty"`
+	Blob_sha                        string `json:"blob_sha,omitempty"`
+	Blob_url                        string `json:"blob_url,omitempty"`
+	Commit_sha                      string `json:"commit_sha,omitempty"`
+	Commit_url                      string `json:"commit_url,omitempty"`
+	Page_url                        string `json:"page_url,omitempty"`
+	Issue_title_url                 string `json:"issue_title_url,omitempty"`
+	Issue_body_url                  string `json:"issue_body_url,omitempty"`
+	Issue_comment_url               string `json:"issue_comment_url,omitempty"`
+	Discussion_title_url            string `json:"discussion_title_url,omitempty"`
+	Discussion_body_url             string `json:"discussion_body_url,omitempty"`
+	Discussion_comment_url          string `json:"discussion_comment_url,omitempty"`
+	Pull_request_title_url          string `json:"pull_request_title_url,omitempty"`
+	Pull_request_body_url           string `json:"pull_request_body_url,omitempty"`
+	Pull_request_comment_url        string `json:"pull_request_comment_url,omitempty"`
+	Pull_request_review_url         string `json:"pull_request_review_url,omitempty"`
+	Pull_request_review_comment_url string `json:"pull_request_review_comment_url,omitempty"`
+}
+
+func fetchLocations() bool {
+	// SARIF results need a file location for code scanning to show them:
+	return showLocations || sarifReport
+}
+
+func (l Location) isFile() bool {
+	return l.Type == "commit" || l.Type == "wiki_commit"
+}
+
+func (l Location) url() string {
+	// non-file locations only set the URL field matching their type:
+	for _, url := range []string{
+		l.Details.Issue_title_url, l.Details.Issue_body_url, l.Details.Issue_comment_url,
+		l.Details.Discussion_title_url, l.Details.Discussion_body_url, l.Details.Discussion_comment_url,
+		l.Details.Pull_request_title_url, l.Details.Pull_request_body_url, l.Details.Pull_request_comment_url,
+		l.Details.Pull_request_review_url, l.Details.Pull_request_review_comment_url,
+		l.Details.Page_url, l.Details.Blob_url,
+	} {
+		if url != "" {
+			return url
+		}
+	}
+	return ""
+}
+
+func (l Location) String() string {
+	if !l.isFile() {
+		return l.Type + " " + l.url()
+	}
+	// e.g. "config/settings.yml:10-12 @ 1a2b3c4":
+	location := l.Details.Path
+	if l.Type == "wiki_commit" {
+		location = "wiki " + location
+	}
+	if l.Details.Start_line > 0 {
+		location += ":" + strconv.Itoa(l.Details.Start_line)
+		if l.Details.End_line > l.Details.Start_line {
+			location += "-" + strconv.Itoa(l.Details.End_line)
+		}
+	}
+	if l.Details.Commit_sha != "" {
+		location += " @ " + l.Details.Commit_sha[:min(len(l.Details.Commit_sha), 7)]
+	}
+	return location
+}
+
+func summarizeLocations(locations []Location) string {
+	// show the first location, and how many others there are:
+	switch len(locations) {
+	case 0:
+		return ""
+	case 1:
+		return locations[0].String()
+	default:
+		return locations[0].String() + " (+" + strconv.Itoa(len(locations)-1) + " more)"
+	}
+}
+
+func joinLocations(locations []Location) string {
+	var parts []string
+	for _, location := range locations {
+		parts = append(parts, location.String())
+	}
+	return strings.Join(parts, "; ")
+}
+
+func createGitHubSecretAlertLocationsAPIPath(alert Alert) string {
+	// the alert's API URL is absolute and already points at the right host:
+	if alert.URL != "" {
+		return alert.URL + "/locations?per_page=100"
+	}
+	return "repos/" + alert.Repository.Full_name + "/secret-scanning/alerts/" + strconv.Itoa(alert.Number) + "/locations?per_page=100"
+}
+
+func addLocationsToAlerts(ctx context.Context, client *api.RESTClient, alerts []Alert) (err error) {
+	for i := range alerts {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		requestPath := createGitHubSecretAlertLocationsAPIPath(alerts[i])
+		var locations []Location
+		for {
+			var pageOfLocations []Location
+			_, nextPage, err := callGitHubAPIWithContext(ctx, client, requestPath, &pageOfLocations, GET)
+			if err != nil {
+				return fmt.Errorf("unable to get locations for alert %d in %s: %w", alerts[i].Number, alerts[i].Repository.Full_name, err)
+			}
+			locations = append(locations, pageOfLocations...)
+			var hasNextPage bool
+			if requestPath, hasNextPage = findNextPage(nextPage); !hasNextPage {
+				break
+			}
+		}
+		alerts[i].Locations = locations
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/cli/go-gh/pkg/term"
+	"github.com/cli/go-gh/v2/pkg/jq"
+	"github.com/cli/go-gh/v2/pkg/template"
+	"github.com/spf13/cobra"
+)
+
+var jsonFields []string
+var jqExpression string
+var templateString string
+var ndjson bool
+
+func jsonOutput() bool {
+	return len(jsonFields) > 0
+}
+
+// logOutput is where progress messages, warnings and prompts are written. They move to stderr when stdout
+// carries JSON, so that it stays machine-readable.
+func logOutput() io.Writer {
+	if jsonOutput() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+func jsonFieldsOf(value interface{}) (fields []string) {
+	// the exported field names are the JSON names of the struct's fields:
+	valueType := reflect.TypeOf(value)
+	for i := 0; i < valueType.NumField(); i++ {
+		name := strings.Split(valueType.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func availableJSONFields(cmd *cobra.Command) []string {
+	// subcommands that don't report alerts export their own result type:
+	switch cmd.Name() {
+	case "coverage":
+		return jsonFieldsOf(RepositoryCoverage{})
+	case "history":
+		return jsonFieldsOf(AlertRecord{})
+	case "diff":
+		return jsonFieldsOf(AlertChange{})
+	case "enable":
+		return jsonFieldsOf(EnableResult{})
+	}
+	return jsonFieldsOf(Alert{})
+}
+
+func validateOutputFlags(availableFields []string) error {
+	if !jsonOutput() {
+		if jqExpression != "" || templateString != "" || ndjson {
+			return errors.New("cannot use --jq, --template or --ndjson without specifying --json")
+		}
+		return nil
+	}
+	for _, field := range jsonFields {
+		if !slices.Contains(availableFields, field) {
+			return fmt.Errorf("Unknown JSON field: %q\nAvailable fields:\n  %s", field, strings.Join(availableFields, "\n  "))
+		}
+		if field == "secret" && !secret {
+			return errors.New("the secret field requires the --show-secret flag")
+		}
+	}
+	if jqExpression != "" && templateString != "" {
+		return errors.New("only one of --jq or --template may be used")
+	}
+	if (jqExpression != "" || templateString != "") && (ndjson || stream) {
+		return errors.New("--jq and --template operate on the complete result and cannot be combined with --ndjson or --stream")
+	}
+	return nil
+}
+
+func exportFields(item interface{}) (exported map[string]interface{}, err error) {
+	// round trip through JSON so that nested structs are exported with their JSON names:
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]interface{}
+	if err = json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	exported = make(map[string]interface{}, len(jsonFields))
+	for _, field := range jsonFields {
+		exported[field] = all[field]
+	}
+	return exported, nil
+}
+
+func writeJSON[T any](w io.Writer, items []T) error {
+	exported := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		exportedItem, err := exportFields(item)
+		if err != nil {
+			return err
+		}
+		exported = append(exported, exportedItem)
+	}
+	data, err := json.MarshalIndent(exported, "", "  ")
+	if err != nil {
+		return err
+	}
+	terminal := term.FromEnv()
+	switch {
+	case jqExpression != "":
+		return jq.EvaluateFormatted(bytes.NewReader(data), w, jqExpression, "  ", terminal.IsColorEnabled())
+	case templateString != "":
+		termWidth, _, _ := terminal.Size()
+		t := template.New(w, termWidth, terminal.IsColorEnabled())
+		if err := t.Parse(templateString); err != nil {
+			return err
+		}
+		if err := t.Execute(bytes.NewReader(data)); err != nil {
+			return err
+		}
+		return t.Flush()
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func writeNDJSON[T any](w io.Writer, items []T) error {
+	// one compact JSON object per line, so that consumers can process results as they arrive:
+	encoder := json.NewEncoder(w)
+	for _, item := range items {
+		exportedItem, err := exportFields(item)
+		if err != nil {
+			return err
+		}
+		if err = encoder.Encode(exportedItem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
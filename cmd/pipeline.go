@@ -0,0 +1,332 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+func getPerPage() int {
+	// request at most 100 alerts per page, the maximum supported by the API:
+	return min(limit, 100)
+}
+
+func setPaginationParameters(values url.Values) {
+	values.Set("per_page", strconv.Itoa(getPerPage()))
+	// an empty cursor opts into cursor based pagination, so that the Link header points at the alerts after
+	// the last one received instead of at a page number that drifts as alerts are created or resolved.
+	// Servers that don't support cursors ignore it and return page numbers, which are followed the same way:
+	values.Set("after", "")
+}
+
+// maximum number of targets whose alerts are fetched at the same time:
+const maxConcurrentFetches = 4
+
+// alertSource is the alerts request for a single target, the checkpoint its pages are saved to, and with
+// --sync, the cursor of the previous sync.
+type alertSource struct {
+	target      Target
+	requestPath string
+	checkpoint  *checkpoint
+	sync        *syncCursor
+}
+
+func newAlertSources(command string, targets []Target, values url.Values) (sources []alertSource, err error) {
+	for _, target := range targets {
+		requestPath, err := createGitHubSecretAlertsAPIPath(target.Scope, target.Name)
+		if err != nil {
+			return nil, err
+		}
+		requestPath += "?" + values.Encode()
+		sources = append(sources, alertSource{target: target, requestPath: requestPath, checkpoint: newCheckpoint(command, requestPath)})
+	}
+	return sources, nil
+}
+
+func removeCheckpoints(sources []alertSource) (err error) {
+	// the run completed, so there is nothing left to resume:
+	for _, source := range sources {
+		err = errors.Join(err, source.checkpoint.remove())
+	}
+	return err
+}
+
+// fetchAlertPages fetches the alerts of every source, up to maxConcurrentFetches at a time, sending each
+// page on as soon as it arrives. The first error stops the remaining fetches. The pages channel is closed
+// on return.
+func fetchAlertPages(ctx context.Context, client *api.RESTClient, sources []alertSource, pages chan<- []Alert) (err error) {
+	defer close(pages)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	semaphore := make(chan struct{}, maxConcurrentFetches)
+	for _, source := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+				return
+			}
+			if fetchErr := fetchSourceAlertPages(ctx, client, source, len(sources) > 1, pages); fetchErr != nil {
+				mutex.Lock()
+				defer mutex.Unlock()
+				// only report the error that stopped the other fetches:
+				if err == nil {
+					err = fetchErr
+					if len(sources) > 1 {
+						err = fmt.Errorf("%s: %w", source.target.Name, fetchErr)
+					}
+				}
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	if err == nil {
+		err = ctx.Err()
+	}
+	return err
+}
+
+// fetchSourceAlertPages follows the Link header until all pages have been fetched or the limit has been
+// reached, sending each page on as soon as it arrives, and saving it to the checkpoint. With --resume,
+// the alerts saved by the previous run are sent first and fetching continues from where it stopped. With
+// --sync, fetching stops at the first alert that hasn't changed since the previous sync.
+func fetchSourceAlertPages(ctx context.Context, client *api.RESTClient, source alertSource, named bool, pages chan<- []Alert) error {
+	checkpoint := source.checkpoint
+	defer checkpoint.close()
+	requestPath := source.requestPath
+	// name the target in progress messages when several are fetched at once:
+	var suffix string
+	if named {
+		suffix = " (" + source.target.Name + ")"
+	}
+	fetched := 0
+	if resume {
+		restoredAlerts, nextPage, err := checkpoint.restore()
+		switch {
+		case errors.Is(err, errNoCheckpoint):
+			// the previous run may have stopped before this target's first page was saved:
+			if !quiet {
+				fmt.Println(Yellow("No checkpoint to resume" + suffix + ", starting from the first page."))
+			}
+		case err != nil:
+			return err
+		default:
+			if !quiet {
+				fmt.Println(Blue("Resuming from checkpoint: " + strconv.Itoa(len(restoredAlerts)) + " secret alerts already fetched" + suffix + "."))
+			}
+			fetched = len(restoredAlerts)
+			select {
+			case pages <- restoredAlerts:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			// the previous run may have stopped after fetching every page, or before saving the first one:
+			if fetched > 0 && (nextPage == "" || fetched >= limit) {
+				return nil
+			}
+			if nextPage != "" {
+				requestPath = nextPage
+			}
+		}
+	}
+	for page := 1; ; page++ {
+		if !quiet {
+			fmt.Println("Processing page: " + strconv.Itoa(page) + suffix)
+		}
+		var pageOfSecretAlerts []Alert
+		_, nextPage, err := callGitHubAPIWithContext(ctx, client, requestPath, &pageOfSecretAlerts, GET)
+		if err != nil {
+			return err
+		}
+		// if a specific repo endpoint was targeted, add the repo field to the alerts:
+		if source.target.Scope == "repository" {
+			pageOfSecretAlerts = addRepoFullNameToAlerts(pageOfSecretAlerts, source.target.Name)
+		}
+		// only keep the alerts that changed since the previous sync:
+		var reachedSynced bool
+		if source.sync != nil {
+			if pageOfSecretAlerts, reachedSynced, err = source.sync.changed(pageOfSecretAlerts); err != nil {
+				return err
+			}
+		}
+		// optionally look up where each secret was found, at the cost of one request per alert:
+		if fetchLocations() {
+			if err = addLocationsToAlerts(ctx, client, pageOfSecretAlerts); err != nil {
+				return err
+			}
+		}
+		fetched += len(pageOfSecretAlerts)
+		var hasNextPage bool
+		requestPath, hasNextPage = findNextPage(nextPage)
+		if reachedSynced {
+			if !quiet {
+				fmt.Println(Blue("Reached the secret alerts already synced" + suffix + "."))
+			}
+			requestPath, hasNextPage = "", false
+		}
+		if err = checkpoint.save(pageOfSecretAlerts, requestPath); err != nil {
+			return err
+		}
+		select {
+		case pages <- pageOfSecretAlerts:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if !hasNextPage {
+			// every changed alert has been fetched, so the sync cursor can move on:
+			if source.sync != nil {
+				source.sync.complete = true
+			}
+			return nil
+		}
+		if fetched >= limit {
+			return nil
+		}
+	}
+}
+
+// verifyAlertPages verifies each page of alerts as it arrives and passes it on. Verification errors
+// don't stop the pipeline, they are returned once every page has been handled.
+func verifyAlertPages(ctx context.Context, pages <-chan []Alert, verified chan<- []Alert) error {
+	defer close(verified)
+	var errs []error
+	for page := range pages {
+		verifiedPage, err := verifyAlerts(ctx, page)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		select {
+		case verified <- verifiedPage:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// alertReport emits alerts to the console and the optional csv report. By default alerts are buffered
+// and sorted once every page has been received; with --stream each page is emitted as it arrives.
+type alertReport struct {
+	scope          string
+	validity_check bool
+	alerts         []Alert
+	counter        int
+	csv            *csvReportWriter
+	sarif          *sarifReportWriter
+	client         *api.RESTClient
+}
+
+func newAlertReport(client *api.RESTClient, scope string, validity_check bool) *alertReport {
+	return &alertReport{client: client, scope: scope, validity_check: validity_check}
+}
+
+func (r *alertReport) add(alerts []Alert) (err error) {
+	if !stream {
+		r.alerts = append(r.alerts, alerts...)
+		return nil
+	}
+	// only emit up to the limit across all pages:
+	alerts = alerts[:min(len(alerts), max(limit-r.counter, 0))]
+	if len(alerts) == 0 {
+		return nil
+	}
+	if jsonOutput() {
+		if err = writeNDJSON(os.Stdout, alerts); err != nil {
+			return err
+		}
+	} else if !quiet {
+		if err = printAlertsTable(alerts, r.validity_check, r.counter == 0); err != nil {
+			return err
+		}
+	}
+	if csvReport {
+		if r.csv == nil {
+			if r.csv, err = newCSVReportWriter(r.scope, r.validity_check); err != nil {
+				return err
+			}
+		}
+		if err = r.csv.writeAlerts(alerts); err != nil {
+			return err
+		}
+	}
+	if sarifReport {
+		if r.sarif == nil {
+			r.sarif = newSARIFReportWriter(r.client, r.scope, r.validity_check)
+		}
+		r.sarif.writeAlerts(alerts)
+	}
+	r.counter += len(alerts)
+	return nil
+}
+
+func (r *alertReport) finish() (err error) {
+	if stream {
+		if !quiet {
+			fmt.Println(Blue("Fetched " + strconv.Itoa(r.counter) + " secret alerts."))
+		}
+		if r.csv != nil {
+			csv := r.csv
+			r.csv = nil
+			if err = csv.close(); err != nil {
+				return err
+			}
+		}
+		if r.sarif != nil {
+			err = r.sarif.close()
+		}
+		return err
+	}
+
+	// sort all alerts by repository name, and then by secret alert ID:
+	sortedAlerts := sortAlerts(r.alerts)
+
+	// write JSON, or pretty print all of the response details:
+	if jsonOutput() {
+		limitedAlerts := sortedAlerts[:min(len(sortedAlerts), limit)]
+		if ndjson {
+			err = writeNDJSON(os.Stdout, limitedAlerts)
+		} else {
+			err = writeJSON(os.Stdout, limitedAlerts)
+		}
+		if err != nil {
+			return err
+		}
+	} else if !quiet {
+		if err = prettyPrintAlerts(sortedAlerts, r.validity_check); err != nil {
+			return err
+		}
+	}
+
+	// optionally generate a csv report of the results:
+	if len(sortedAlerts) > 0 && csvReport {
+		if err = generateCSVReport(sortedAlerts, r.scope, r.validity_check); err != nil {
+			return err
+		}
+	}
+
+	// optionally generate a SARIF report of the results:
+	if len(sortedAlerts) > 0 && sarifReport {
+		err = generateSARIFReport(r.client, sortedAlerts, r.scope, r.validity_check)
+	}
+	return err
+}
+
+func (r *alertReport) close() {
+	// release the csv report if the pipeline stopped before finish:
+	if r.csv != nil {
+		r.csv.close()
+		r.csv = nil
+	}
+}
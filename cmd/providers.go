@@ -1,22 +1,256 @@
 package cmd
 
-var SupportedProviders = map[string]map[string]map[string]string{
-	"github": {
-		"github_personal_access_token": {
-			"ValidationEndpoint": "https://api.github.com",
-			"HttpMethod":         "GET",
-			"ContentType":        "application/vnd.github.v3+json",
-			"ExpectedBodyKey":    "",
-			"ExpectedBodyValue":  "",
-		},
-	},
-	"slack": {
-		"slack_api_token": {
-			"ValidationEndpoint": "https://slack.com/api/auth.test",
-			"HttpMethod":         "POST",
-			"ContentType":        "application/json",
-			"ExpectedBodyKey":    "ok",
-			"ExpectedBodyValue":  "true",
-		},
-	},
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// ValidatorConfig describes how to confirm that a secret of a given type is still active.
+type ValidatorConfig struct {
+	ValidationEndpoint string            `yaml:"validation_endpoint" json:"validation_endpoint"`
+	HttpMethod         string            `yaml:"http_method" json:"http_method"`
+	ContentType        string            `yaml:"content_type" json:"content_type"`
+	Headers            map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	ExpectedBodyKey    string            `yaml:"expected_body_key,omitempty" json:"expected_body_key,omitempty"`
+	ExpectedBodyValue  string            `yaml:"expected_body_value,omitempty" json:"expected_body_value,omitempty"`
+	RateLimit          *RateLimitConfig  `yaml:"rate_limit,omitempty" json:"rate_limit,omitempty"`
+}
+
+// ProvidersFile is the on-disk format of a custom providers file (YAML or JSON).
+type ProvidersFile struct {
+	Providers map[string]map[string]ValidatorConfig `yaml:"providers" json:"providers"`
+}
+
+// secretPlaceholder is replaced with the secret value in custom header values:
+const secretPlaceholder = "{{secret}}"
+
+// SupportedProviders is the validator registry, keyed by provider and then secret type:
+var SupportedProviders = map[string]map[string]Validator{}
+
+func init() {
+	registerValidator("github", "github_personal_access_token", GitHubTokenValidator{newHTTPValidator("github", ValidatorConfig{
+		ValidationEndpoint: "https://api.github.com",
+		HttpMethod:         "GET",
+		ContentType:        "application/vnd.github.v3+json",
+	})})
+	registerValidator("slack", "slack_api_token", newHTTPValidator("slack", ValidatorConfig{
+		ValidationEndpoint: "https://slack.com/api/auth.test",
+		HttpMethod:         "POST",
+		ContentType:        "application/json",
+		ExpectedBodyKey:    "ok",
+		ExpectedBodyValue:  "true",
+	}))
+	// default request rates for the built-in providers, shared by secret types without their own rate_limit:
+	providerLimiter("github").setLimit(RateLimitConfig{RequestsPerSecond: 10, Burst: 10})
+	providerLimiter("slack").setLimit(RateLimitConfig{RequestsPerSecond: 5, Burst: 5})
+}
+
+func defaultProvidersFile() string {
+	// look for a providers file in the gh config dir, e.g. ~/.config/gh/gh-secret-scanning/providers.yml:
+	dir := filepath.Join(config.ConfigDir(), "gh-secret-scanning")
+	for _, name := range []string{"providers.yml", "providers.yaml", "providers.json"} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+func loadProvidersFile(path string) (err error) {
+	// an explicit --providers-file must exist, the default location is optional:
+	if path == "" {
+		path = defaultProvidersFile()
+		if path == "" {
+			return nil
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read providers file: %w", err)
+	}
+	providersFile, err := decodeProvidersFile(data)
+	if err != nil {
+		return fmt.Errorf("unable to parse providers file %s:\n%w", path, err)
+	}
+	if err := validateProvidersFile(providersFile); err != nil {
+		return fmt.Errorf("invalid providers file %s:\n%w", path, err)
+	}
+	mergeProviders(providersFile.Providers)
+	if verbose {
+		fmt.Fprintln(logOutput(), Blue("Loaded custom providers from "+path))
+	}
+	return nil
+}
+
+func decodeProvidersFile(data []byte) (providersFile ProvidersFile, err error) {
+	// YAML is a superset of JSON, so a single decoder handles both formats:
+	var document yaml.Node
+	if err = yaml.Unmarshal(data, &document); err != nil {
+		return providersFile, err
+	}
+	// a misspelled key would otherwise silently fall back to a default, so name every one of them:
+	if errs := unknownKeys(&document, reflect.TypeOf(providersFile), ""); len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+		return providersFile, errors.Join(errs...)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err = decoder.Decode(&providersFile); err != nil && !errors.Is(err, io.EOF) {
+		return providersFile, err
+	}
+	return providersFile, nil
+}
+
+// unknownKeys returns an error for every mapping key in node that has no matching yaml field in t. Errors
+// are prefixed with the provider and secret type they belong to, e.g. "acme.acme_api_token".
+func unknownKeys(node *yaml.Node, t reflect.Type, path string) (errs []error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, content := range node.Content {
+			errs = append(errs, unknownKeys(content, t, path)...)
+		}
+		return errs
+	case yaml.AliasNode:
+		return unknownKeys(node.Alias, t, path)
+	case yaml.MappingNode:
+	default:
+		return nil
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		switch t.Kind() {
+		case reflect.Map:
+			errs = append(errs, unknownKeys(value, t.Elem(), joinKeyPath(path, key.Value))...)
+		case reflect.Struct:
+			field, ok := yamlField(t, key.Value)
+			if !ok {
+				errs = append(errs, keyError(path, fmt.Errorf("unknown key %q on line %d", key.Value, key.Line)))
+				continue
+			}
+			// the top level "providers" key is left out of the prefix, to match the validation errors:
+			child := ""
+			if path != "" {
+				child = joinKeyPath(path, key.Value)
+			}
+			errs = append(errs, unknownKeys(value, field.Type, child)...)
+		}
+	}
+	return errs
+}
+
+func yamlField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if name, _, _ := strings.Cut(field.Tag.Get("yaml"), ","); name == key {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func joinKeyPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func keyError(path string, err error) error {
+	if path == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %w", path, err)
+}
+
+func mergeProviders(providers map[string]map[string]ValidatorConfig) {
+	// custom secret types are added to, or replace, the built-in validators:
+	for provider, secretTypes := range providers {
+		for secretType, validatorConfig := range secretTypes {
+			validatorConfig.HttpMethod = strings.ToUpper(validatorConfig.HttpMethod)
+			registerValidator(provider, secretType, newHTTPValidator(provider, validatorConfig))
+		}
+	}
+}
+
+var headerNamePattern = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+func validateProvidersFile(providersFile ProvidersFile) error {
+	if len(providersFile.Providers) == 0 {
+		return errors.New("no providers defined under the 'providers' key")
+	}
+	var errs []error
+	for provider, secretTypes := range providersFile.Providers {
+		if strings.TrimSpace(provider) == "" {
+			errs = append(errs, errors.New("provider names must not be empty"))
+			continue
+		}
+		if len(secretTypes) == 0 {
+			errs = append(errs, fmt.Errorf("%s: no secret types defined", provider))
+		}
+		for secretType, validatorConfig := range secretTypes {
+			for _, err := range validateValidatorConfig(validatorConfig) {
+				errs = append(errs, fmt.Errorf("%s.%s: %w", provider, secretType, err))
+			}
+		}
+	}
+	// sort so that repeated runs report problems in the same order:
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errors.Join(errs...)
+}
+
+func validateValidatorConfig(validatorConfig ValidatorConfig) (errs []error) {
+	endpoint, err := url.Parse(validatorConfig.ValidationEndpoint)
+	if validatorConfig.ValidationEndpoint == "" {
+		errs = append(errs, errors.New("validation_endpoint is required"))
+	} else if err != nil || (endpoint.Scheme != "https" && endpoint.Scheme != "http") || endpoint.Host == "" {
+		errs = append(errs, fmt.Errorf("validation_endpoint must be an absolute http(s) URL: %q", validatorConfig.ValidationEndpoint))
+	}
+	switch strings.ToUpper(validatorConfig.HttpMethod) {
+	case http.MethodGet, http.MethodPost:
+	default:
+		errs = append(errs, fmt.Errorf("http_method must be GET or POST: %q", validatorConfig.HttpMethod))
+	}
+	if validatorConfig.ContentType == "" {
+		errs = append(errs, errors.New("content_type is required"))
+	} else if _, _, err := mime.ParseMediaType(validatorConfig.ContentType); err != nil {
+		errs = append(errs, fmt.Errorf("content_type is not a valid media type: %q", validatorConfig.ContentType))
+	}
+	for name, value := range validatorConfig.Headers {
+		if !headerNamePattern.MatchString(name) {
+			errs = append(errs, fmt.Errorf("invalid header name: %q", name))
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			errs = append(errs, fmt.Errorf("header %s must not contain line breaks", name))
+		}
+	}
+	if validatorConfig.ExpectedBodyValue != "" && validatorConfig.ExpectedBodyKey == "" {
+		errs = append(errs, errors.New("expected_body_value requires expected_body_key"))
+	}
+	if validatorConfig.RateLimit != nil {
+		if validatorConfig.RateLimit.RequestsPerSecond <= 0 {
+			errs = append(errs, errors.New("rate_limit.requests_per_second must be greater than 0"))
+		}
+		if validatorConfig.RateLimit.Burst < 0 {
+			errs = append(errs, errors.New("rate_limit.burst must not be negative"))
+		}
+	}
+	return errs
 }
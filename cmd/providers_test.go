@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProviderFlagIsCaseInsensitive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "providers.yml")
+	content := `providers:
+  Acme:
+    acme_api_token:
+      validation_endpoint: https://api.acme.example/v1/whoami
+      http_method: GET
+      content_type: application/json
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	providersFile, provider = path, "Acme"
+	// diff --list doesn't need targets:
+	listSnapshots = true
+	defer func() {
+		providersFile, provider, listSnapshots = "", "", false
+		delete(SupportedProviders, "acme")
+	}()
+	if err := rootCmd.PersistentPreRunE(diffCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := getSecretTypeParameter(); got != "acme_api_token" {
+		t.Errorf("getSecretTypeParameter() = %q, want %q", got, "acme_api_token")
+	}
+}
+
+func TestSecretTypeRateLimit(t *testing.T) {
+	mergeProviders(map[string]map[string]ValidatorConfig{"acme": {
+		"acme_api_token":   {ValidationEndpoint: "https://api.acme.example/v1/whoami", HttpMethod: "get"},
+		"acme_deploy_key":  {ValidationEndpoint: "https://api.acme.example/v1/keys", HttpMethod: "get"},
+		"acme_session_key": {ValidationEndpoint: "https://api.acme.example/v1/session", HttpMethod: "get", RateLimit: &RateLimitConfig{RequestsPerSecond: 1}},
+	}})
+	defer delete(SupportedProviders, "acme")
+	limiter := func(secretType string) *tokenBucket {
+		return SupportedProviders["acme"][secretType].(HTTPValidator).limiter
+	}
+	// secret types without a rate limit share the provider's budget, which a rate limit of another type doesn't change:
+	if limiter("acme_api_token") != limiter("acme_deploy_key") || limiter("acme_api_token").rate != 0 {
+		t.Errorf("secret types without a rate limit don't share the provider's unlimited budget")
+	}
+	if session := limiter("acme_session_key"); session == limiter("acme_api_token") || session.rate != 1 {
+		t.Errorf("secret type with a rate limit doesn't have its own budget of 1 request per second")
+	}
+}
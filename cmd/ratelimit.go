@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// RateLimitConfig limits how quickly validation requests are sent for a secret type or provider.
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `yaml:"requests_per_second" json:"requests_per_second"`
+	Burst             int     `yaml:"burst,omitempty" json:"burst,omitempty"`
+}
+
+// maximum number of times a throttled request is retried before giving up:
+const maxThrottleRetries = 5
+
+// tokenBucket is a simple token-bucket rate limiter. A bucket without a rate never blocks.
+type tokenBucket struct {
+	mutex    sync.Mutex
+	rate     float64
+	burst    float64
+	tokens   float64
+	lastFill time.Time
+}
+
+// providerLimiters holds one shared bucket per provider, which its secret types without a rate_limit draw from:
+var providerLimiters = map[string]*tokenBucket{}
+var providerLimitersMutex sync.Mutex
+
+func providerLimiter(provider string) *tokenBucket {
+	providerLimitersMutex.Lock()
+	defer providerLimitersMutex.Unlock()
+	limiter, ok := providerLimiters[provider]
+	if !ok {
+		limiter = &tokenBucket{}
+		providerLimiters[provider] = limiter
+	}
+	return limiter
+}
+
+func (b *tokenBucket) setLimit(rateLimit RateLimitConfig) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.rate = rateLimit.RequestsPerSecond
+	b.burst = float64(max(rateLimit.Burst, 1))
+	b.tokens = b.burst
+	b.lastFill = time.Now()
+}
+
+// Wait blocks until a token is available or the context is cancelled.
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	for {
+		b.mutex.Lock()
+		if b.rate <= 0 {
+			b.mutex.Unlock()
+			return nil
+		}
+		now := time.Now()
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.lastFill).Seconds()*b.rate)
+		b.lastFill = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mutex.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mutex.Unlock()
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func throttleWait(statusCode int, header http.Header, attempt int) (wait time.Duration, throttled bool) {
+	// 429 is always a throttle, 403 only when the rate limit headers say the budget is spent:
+	switch statusCode {
+	case http.StatusTooManyRequests:
+	case http.StatusForbidden:
+		if header.Get("Retry-After") == "" && header.Get("X-RateLimit-Remaining") != "0" {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	return rateLimitWait(header, attempt, time.Now()), true
+}
+
+func apiThrottleWait(httpError *api.HTTPError, attempt int) (wait time.Duration, throttled bool) {
+	// secondary rate limits are a 403 that doesn't always include rate limit headers, in which case GitHub
+	// asks for at least a minute between retries:
+	if httpError.StatusCode == http.StatusForbidden && httpError.Headers.Get("Retry-After") == "" && strings.Contains(strings.ToLower(httpError.Message), "secondary rate limit") {
+		return time.Minute << attempt, true
+	}
+	return throttleWait(httpError.StatusCode, httpError.Headers, attempt)
+}
+
+func reportRateLimit(header http.Header) {
+	// GHES instances can have rate limiting disabled, in which case there are no headers:
+	remaining := header.Get("X-RateLimit-Remaining")
+	if remaining == "" {
+		return
+	}
+	budget := "API rate limit: " + remaining + "/" + header.Get("X-RateLimit-Limit") + " requests remaining"
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		budget += ", resets at " + time.Unix(reset, 0).Format(time.TimeOnly)
+	}
+	fmt.Println(Gray(budget))
+}
+
+func rateLimitWait(header http.Header, attempt int, now time.Time) time.Duration {
+	// Retry-After is either a number of seconds or an HTTP date:
+	if retryAfter := header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+		if date, err := http.ParseTime(retryAfter); err == nil {
+			return max(date.Sub(now), 0)
+		}
+	}
+	// X-RateLimit-Reset is the unix time at which the budget is refilled:
+	if reset := header.Get("X-RateLimit-Reset"); reset != "" {
+		if epoch, err := strconv.ParseInt(reset, 10, 64); err == nil {
+			return max(time.Unix(epoch, 0).Sub(now), 0) + time.Second
+		}
+	}
+	// otherwise back off exponentially, starting at one second:
+	return time.Second << attempt
+}
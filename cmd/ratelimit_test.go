@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+func TestRateLimitWait(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name    string
+		header  http.Header
+		attempt int
+		want    time.Duration
+	}{
+		{"retry after seconds", http.Header{"Retry-After": {"30"}}, 0, 30 * time.Second},
+		{"retry after date", http.Header{"Retry-After": {now.Add(time.Minute).Format(http.TimeFormat)}}, 0, time.Minute},
+		{"retry after date in the past", http.Header{"Retry-After": {now.Add(-time.Minute).Format(http.TimeFormat)}}, 0, 0},
+		{"retry after takes precedence", http.Header{"Retry-After": {"5"}, "X-Ratelimit-Reset": {strconv.FormatInt(now.Add(time.Hour).Unix(), 10)}}, 0, 5 * time.Second},
+		{"rate limit reset", http.Header{"X-Ratelimit-Reset": {strconv.FormatInt(now.Add(90*time.Second).Unix(), 10)}}, 0, 91 * time.Second},
+		{"rate limit reset in the past", http.Header{"X-Ratelimit-Reset": {strconv.FormatInt(now.Add(-time.Minute).Unix(), 10)}}, 0, time.Second},
+		{"invalid retry after backs off", http.Header{"Retry-After": {"soon"}}, 2, 4 * time.Second},
+		{"first backoff", http.Header{}, 0, time.Second},
+		{"third backoff", http.Header{}, 3, 8 * time.Second},
+	}
+	for _, test := range tests {
+		if got := rateLimitWait(test.header, test.attempt, now); got != test.want {
+			t.Errorf("%s: rateLimitWait() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestThrottleWait(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		header     http.Header
+		want       time.Duration
+		throttled  bool
+	}{
+		{"ok", http.StatusOK, http.Header{"Retry-After": {"10"}}, 0, false},
+		{"unauthorized", http.StatusUnauthorized, http.Header{}, 0, false},
+		{"too many requests", http.StatusTooManyRequests, http.Header{"Retry-After": {"10"}}, 10 * time.Second, true},
+		{"too many requests without headers", http.StatusTooManyRequests, http.Header{}, time.Second, true},
+		{"forbidden with retry after", http.StatusForbidden, http.Header{"Retry-After": {"10"}}, 10 * time.Second, true},
+		{"forbidden with exhausted rate limit", http.StatusForbidden, http.Header{"X-Ratelimit-Remaining": {"0"}}, time.Second, true},
+		{"forbidden with remaining rate limit", http.StatusForbidden, http.Header{"X-Ratelimit-Remaining": {"42"}}, 0, false},
+		{"forbidden without headers", http.StatusForbidden, http.Header{}, 0, false},
+	}
+	for _, test := range tests {
+		wait, throttled := throttleWait(test.statusCode, test.header, 0)
+		if wait != test.want || throttled != test.throttled {
+			t.Errorf("%s: throttleWait() = %v, %t, want %v, %t", test.name, wait, throttled, test.want, test.throttled)
+		}
+	}
+}
+
+func TestAPIThrottleWait(t *testing.T) {
+	tests := []struct {
+		name      string
+		httpError api.HTTPError
+		attempt   int
+		want      time.Duration
+		throttled bool
+	}{
+		{"secondary rate limit", api.HTTPError{StatusCode: http.StatusForbidden, Message: "You have exceeded a secondary rate limit.", Headers: http.Header{}}, 0, time.Minute, true},
+		{"repeated secondary rate limit", api.HTTPError{StatusCode: http.StatusForbidden, Message: "You have exceeded a secondary rate limit.", Headers: http.Header{}}, 2, 4 * time.Minute, true},
+		{"secondary rate limit with retry after", api.HTTPError{StatusCode: http.StatusForbidden, Message: "You have exceeded a secondary rate limit.", Headers: http.Header{"Retry-After": {"30"}}}, 0, 30 * time.Second, true},
+		{"primary rate limit", api.HTTPError{StatusCode: http.StatusForbidden, Message: "API rate limit exceeded", Headers: http.Header{"X-Ratelimit-Remaining": {"0"}}}, 0, time.Second, true},
+		{"forbidden", api.HTTPError{StatusCode: http.StatusForbidden, Message: "Resource not accessible by integration", Headers: http.Header{}}, 0, 0, false},
+		{"not found", api.HTTPError{StatusCode: http.StatusNotFound, Message: "Not Found", Headers: http.Header{}}, 0, 0, false},
+	}
+	for _, test := range tests {
+		wait, throttled := apiThrottleWait(&test.httpError, test.attempt)
+		if wait != test.want || throttled != test.throttled {
+			t.Errorf("%s: apiThrottleWait() = %v, %t, want %v, %t", test.name, wait, throttled, test.want, test.throttled)
+		}
+	}
+}
+
+func TestCallGitHubAPIStopsWaitingWhenCancelled(t *testing.T) {
+	client := newTestRESTClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	quiet = true
+	defer func() { quiet = false }()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	var response interface{}
+	_, _, err := callGitHubAPIWithContext(ctx, client, "orgs/o/secret-scanning/alerts", &response, GET)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("callGitHubAPIWithContext() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("callGitHubAPIWithContext() returned after %v, want it to stop waiting once cancelled", elapsed)
+	}
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cli/go-gh/pkg/tableprinter"
+	"github.com/cli/go-gh/pkg/term"
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+type alertResolution struct {
+	State              string `json:"state"`
+	Resolution         string `json:"resolution"`
+	Resolution_comment string `json:"resolution_comment"`
+}
+
+func resolutionComment(alert Alert) string {
+	// record the verification evidence on the alert itself, quoting the provider's reason:
+	comment := "Verified inactive by gh-secret-scanning on " + time.Now().UTC().Format(time.RFC3339) + ": " + strconv.Quote(alert.Validity_reason)
+	if alert.Validity_response_code != "" {
+		comment += " (HTTP " + alert.Validity_response_code + " from " + alert.Validity_endpoint + ")"
+	}
+	return comment
+}
+
+func hasRevocationEvidence(alert Alert) bool {
+	// only a 401, or a 200 whose body didn't match the expected value, shows that the provider no longer
+	// accepts the secret. Anything else may be a live secret that couldn't be checked:
+	if alert.Validity_outcome != OutcomeInactive {
+		return false
+	}
+	return alert.Validity_response_code == strconv.Itoa(http.StatusUnauthorized) || alert.Validity_response_code == strconv.Itoa(http.StatusOK)
+}
+
+func createGitHubSecretAlertAPIPath(alert Alert) string {
+	// the alert's API URL is absolute and already points at the right host:
+	if alert.URL != "" {
+		return alert.URL
+	}
+	return "repos/" + alert.Repository.Full_name + "/secret-scanning/alerts/" + strconv.Itoa(alert.Number)
+}
+
+func resolveInactiveAlerts(client *api.RESTClient, alerts []Alert) (err error) {
+	// only open alerts whose secret was confirmed inactive by the provider are resolved:
+	var inactiveAlerts []Alert
+	for _, alert := range alerts {
+		if alert.State == "open" && hasRevocationEvidence(alert) {
+			inactiveAlerts = append(inactiveAlerts, alert)
+		}
+	}
+	if len(inactiveAlerts) == 0 {
+		fmt.Fprintln(logOutput(), Blue("No open alerts with inactive secrets to resolve."))
+		return nil
+	}
+
+	// preview the alerts that will be resolved:
+	fmt.Fprintln(logOutput(), Blue("The following "+strconv.Itoa(len(inactiveAlerts))+" alert(s) will be resolved as revoked:"))
+	terminal := term.FromEnv()
+	termWidth, _, _ := terminal.Size()
+	t := tableprinter.New(logOutput(), terminal.IsTerminalOutput(), termWidth)
+	for _, header := range []string{"Repository", "ID", "Secret Type", "Reason"} {
+		t.AddField(header, tableprinter.WithColor(Green), tableprinter.WithTruncate(nil))
+	}
+	t.EndRow()
+	for _, alert := range inactiveAlerts {
+		t.AddField(alert.Repository.Full_name, tableprinter.WithColor(Gray), tableprinter.WithTruncate(nil))
+		t.AddField(strconv.Itoa(alert.Number), tableprinter.WithColor(Gray), tableprinter.WithTruncate(nil))
+		t.AddField(alert.Secret_type, tableprinter.WithColor(Gray), tableprinter.WithTruncate(nil))
+		t.AddField(alert.Validity_reason, tableprinter.WithColor(Gray), tableprinter.WithTruncate(nil))
+		t.EndRow()
+	}
+	if err = t.Render(); err != nil {
+		return fmt.Errorf("error rendering table: %v", err)
+	}
+
+	if dryRun {
+		fmt.Fprintln(logOutput(), Blue("Dry run: no alerts were resolved."))
+		return nil
+	}
+	if !assumeYes && !confirm(Yellow("Resolve "+strconv.Itoa(len(inactiveAlerts))+" alert(s) as revoked? (y/n)")) {
+		fmt.Fprintln(logOutput(), Blue("No alerts were resolved."))
+		return nil
+	}
+
+	var errs []error
+	resolved_count := 0
+	for _, alert := range inactiveAlerts {
+		body, err := json.Marshal(alertResolution{State: "resolved", Resolution: "revoked", Resolution_comment: resolutionComment(alert)})
+		if err != nil {
+			return err
+		}
+		var updatedAlert Alert
+		_, _, err = callGitHubAPI(client, createGitHubSecretAlertAPIPath(alert), &updatedAlert, PATCH, body)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to resolve alert %d in %s: %w", alert.Number, alert.Repository.Full_name, err))
+			continue
+		}
+		resolved_count++
+		if verbose {
+			fmt.Fprintln(logOutput(), "Resolved alert "+strconv.Itoa(alert.Number)+" in "+alert.Repository.Full_name)
+		}
+	}
+	fmt.Fprintln(logOutput(), Blue("Resolved "+strconv.Itoa(resolved_count)+" alert(s)."))
+	return errors.Join(errs...)
+}
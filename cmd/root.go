@@ -1,39 +1,50 @@
 package cmd
 
 import (
-	"fmt"
+	"errors"
 	"log"
+	"math"
 	"strings"
 
 	"github.com/spf13/cobra"
 )
 
 var host string
-var enterprise string
-var organization string
-var repository string
 var provider string
 var limit int
 var secret bool
 var csvReport bool
+var sarifReport bool
 var verbose bool
 var quiet bool
+var stream bool
+var showLocations bool
+var providersFile string
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&host, "url", "u", "github.com", "GitHub host to connect to")
-	rootCmd.PersistentFlags().StringVarP(&enterprise, "enterprise", "e", "", "GitHub enterprise slug")
-	rootCmd.PersistentFlags().StringVarP(&organization, "organization", "o", "", "GitHub organization slug")
-	rootCmd.PersistentFlags().StringVarP(&repository, "repository", "r", "", "GitHub owner/repository slug")
+	rootCmd.PersistentFlags().StringSliceVarP(&targetEnterprises, "enterprise", "e", nil, "GitHub enterprise slug (repeatable)")
+	rootCmd.PersistentFlags().StringSliceVarP(&targetOrganizations, "organization", "o", nil, "GitHub organization slug (repeatable)")
+	rootCmd.PersistentFlags().StringSliceVarP(&targetRepositories, "repository", "r", nil, "GitHub owner/repository slug (repeatable)")
+	rootCmd.PersistentFlags().StringVar(&targetsFile, "targets-file", "", "Path to a file of targets, one \"organization\", \"owner/repository\" or \"enterprise:slug\" per line")
 	rootCmd.PersistentFlags().StringVarP(&provider, "provider", "p", "", "Filter for a specific secret provider")
-	rootCmd.PersistentFlags().IntVarP(&limit, "limit", "l", 30, "Limit the number of secrets processed")
+	rootCmd.PersistentFlags().IntVarP(&limit, "limit", "l", 30, "Limit the number of secrets processed (0 for no limit)")
 	rootCmd.PersistentFlags().BoolVarP(&secret, "show-secret", "s", false, "Display secret values")
 	rootCmd.PersistentFlags().BoolVarP(&csvReport, "csv", "c", false, "Generate a csv report of the results")
+	rootCmd.PersistentFlags().BoolVar(&sarifReport, "sarif", false, "Generate a SARIF 2.1.0 report of the results")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Include additional secret alert fields")
 	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Minimize output to the console")
-
-	// require exactly one (1) choice of enterprise, organization, or repository:
-	rootCmd.MarkFlagsMutuallyExclusive("enterprise", "organization", "repository")
-	rootCmd.MarkFlagsOneRequired("enterprise", "organization", "repository")
+	rootCmd.PersistentFlags().BoolVar(&showLocations, "locations", false, "Fetch where each secret was found (one extra API request per alert)")
+	rootCmd.PersistentFlags().BoolVar(&stream, "stream", false, "Emit results page by page as they are fetched instead of sorting them at the end")
+	rootCmd.PersistentFlags().StringSliceVar(&jsonFields, "json", nil, "Output JSON with the specified alert fields")
+	rootCmd.PersistentFlags().StringVar(&jqExpression, "jq", "", "Filter JSON output using a jq expression")
+	rootCmd.PersistentFlags().StringVarP(&templateString, "template", "t", "", "Format JSON output using a Go template")
+	rootCmd.PersistentFlags().BoolVar(&ndjson, "ndjson", false, "Output JSON as one alert per line (implied by --stream)")
+	rootCmd.PersistentFlags().BoolVar(&resume, "resume", false, "Continue an alerts or verify run that stopped part way through from its checkpoint")
+	rootCmd.PersistentFlags().StringVar(&stateFile, "state-file", "", "Path to the local history of alerts and verification outcomes (default: <gh state dir>/gh-secret-scanning/state.db)")
+	rootCmd.PersistentFlags().BoolVar(&noState, "no-state", false, "Don't record alerts and verification outcomes in the local history")
+	rootCmd.PersistentFlags().IntVar(&keepSnapshots, "keep-snapshots", 100, "Number of snapshots of alerts and verify runs to keep in the local history (0 to keep all)")
+	rootCmd.PersistentFlags().StringVar(&providersFile, "providers-file", "", "Path to a YAML or JSON file of custom secret validators (default: <gh config dir>/gh-secret-scanning/providers.yml)")
 
 	// disable completion subcommand:
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
@@ -42,16 +53,39 @@ func init() {
 	rootCmd.SilenceUsage = true
 
 	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) (err error) {
+		// require at least one enterprise, organization, or repository:
+		if targetsRequired(cmd, args) && !hasTargetFlags(cmd) {
+			return errors.New("at least one of the flags in the group [" + strings.Join(targetFlags, " ") + "] is required")
+		}
 		// warn user about --show-secret flag:
 		if secret {
-			fmt.Println(Yellow("WARNING: --show-secret flag is enabled. Full secret values will be displayed in PLAIN TEXT in the output. Would you like to continue? (y/n)"))
-			var response string
-			fmt.Scanln(&response)
-			if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
+			if !confirm(Yellow("WARNING: --show-secret flag is enabled. Full secret values will be displayed in PLAIN TEXT in the output. Would you like to continue? (y/n)")) {
 				log.Fatal("Exiting...")
 			}
 		}
-		// check if provider is in supportedProviders:
+		// a limit of 0 processes every alert:
+		if limit < 0 {
+			return errors.New("--limit must be 0 (no limit) or greater")
+		}
+		if limit == 0 {
+			limit = math.MaxInt
+		}
+		if keepSnapshots < 0 {
+			return errors.New("--keep-snapshots must be 0 (keep all) or greater")
+		}
+		// machine-readable output replaces the table, and keeps progress messages off stdout:
+		if err = validateOutputFlags(availableJSONFields(cmd)); err != nil {
+			return err
+		}
+		if jsonOutput() {
+			quiet = true
+		}
+		// merge custom validators over the built-in providers:
+		if err = loadProvidersFile(providersFile); err != nil {
+			return err
+		}
+		// check if provider is in supportedProviders, whose names are registered in lowercase:
+		provider = strings.ToLower(provider)
 		if provider != "" {
 			return validateProvider(provider)
 		}
@@ -69,5 +103,9 @@ var rootCmd = &cobra.Command{
 func Root() {
 	rootCmd.AddCommand(alertsCmd)
 	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(coverageCmd)
+	rootCmd.AddCommand(enableCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(diffCmd)
 	rootCmd.Execute()
 }
@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// minimal subset of the SARIF 2.1.0 object model needed to describe secret alerts:
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool                     sarifTool                 `json:"tool"`
+	VersionControlProvenance []sarifVersionControlInfo `json:"versionControlProvenance,omitempty"`
+	Results                  []sarifResult             `json:"results"`
+}
+
+type sarifVersionControlInfo struct {
+	RepositoryURI string `json:"repositoryUri"`
+	RevisionID    string `json:"revisionId,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name,omitempty"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID              string                 `json:"ruleId"`
+	Level               string                 `json:"level"`
+	Message             sarifMessage           `json:"message"`
+	Locations           []sarifLocation        `json:"locations,omitempty"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints"`
+	Properties          map[string]interface{} `json:"properties"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+func generateSARIFReport(client *api.RESTClient, alerts []Alert, scope string, validity_check bool) (err error) {
+	report := newSARIFReportWriter(client, scope, validity_check)
+	report.writeAlerts(alerts)
+	return report.close()
+}
+
+// sarifReportWriter collects SARIF results as alerts arrive and writes the log file on close, since a
+// SARIF document can't be written incrementally. Code scanning takes the results of one repository at a
+// time, so each repository gets its own run. Secret values are never included.
+type sarifReportWriter struct {
+	client         *api.RESTClient
+	filename       string
+	validity_check bool
+	rules          map[string]sarifRule
+	repositories   []string
+	results        map[string][]sarifResult
+	count          int
+}
+
+func newSARIFReportWriter(client *api.RESTClient, scope string, validity_check bool) *sarifReportWriter {
+	fmt.Fprintln(logOutput(), Blue("Generating SARIF report..."))
+	// Format the time as YYYYMMDD-HHMMSS
+	timestamp := time.Now().Format("20060102-150405")
+	return &sarifReportWriter{
+		client:         client,
+		filename:       "SecretScanningReport-" + scope + "-" + timestamp + ".sarif",
+		validity_check: validity_check,
+		rules:          map[string]sarifRule{},
+		results:        map[string][]sarifResult{},
+	}
+}
+
+func (r *sarifReportWriter) writeAlerts(alerts []Alert) {
+	for _, alert := range alerts {
+		if r.count >= limit {
+			break
+		}
+		// one rule per secret type:
+		if _, ok := r.rules[alert.Secret_type]; !ok {
+			r.rules[alert.Secret_type] = sarifRule{
+				ID:               alert.Secret_type,
+				Name:             alert.Secret_type_display_name,
+				ShortDescription: sarifMessage{Text: "Exposed " + secretTypeName(alert)},
+			}
+		}
+		repository := alert.Repository.Full_name
+		if _, ok := r.results[repository]; !ok {
+			r.repositories = append(r.repositories, repository)
+		}
+		r.results[repository] = append(r.results[repository], r.alertToResult(alert))
+		r.count++
+	}
+}
+
+func (r *sarifReportWriter) alertToResult(alert Alert) sarifResult {
+	// most secret types can't be verified, in which case the validity reported by GitHub is used:
+	status := alert.Validity_github
+	switch alert.Validity_outcome {
+	case OutcomeActive, OutcomeInactive, OutcomeUnknown:
+		if r.validity_check {
+			status = string(alert.Validity_outcome)
+		}
+	}
+	properties := map[string]interface{}{
+		"repository":  alert.Repository.Full_name,
+		"alertNumber": alert.Number,
+		"state":       alert.State,
+		"url":         alert.HTML_URL,
+		"validity":    alert.Validity_github,
+	}
+	if len(alert.Locations) > 0 {
+		properties["locations"] = alert.Locations
+	}
+	if r.validity_check {
+		properties["verification"] = alert.Validity_outcome
+		properties["verificationReason"] = alert.Validity_reason
+	}
+	return sarifResult{
+		RuleID: alert.Secret_type,
+		Level:  sarifLevel(status),
+		Message: sarifMessage{
+			Text: fmt.Sprintf("%s secret scanning alert #%d in %s: %s", secretTypeName(alert), alert.Number, alert.Repository.Full_name, alert.HTML_URL),
+		},
+		PartialFingerprints: map[string]string{
+			"secretScanningAlert/v1": alert.Repository.Full_name + "#" + strconv.Itoa(alert.Number),
+		},
+		Locations:  sarifLocations(alert),
+		Properties: properties,
+	}
+}
+
+func sarifLocations(alert Alert) (sarifLocations []sarifLocation) {
+	// only commit locations map onto a file and region, the others are described by the alert URL:
+	for _, location := range alert.Locations {
+		if location.Type != "commit" || location.Details.Path == "" {
+			continue
+		}
+		physicalLocation := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: location.Details.Path}}
+		if location.Details.Start_line > 0 {
+			physicalLocation.Region = &sarifRegion{
+				StartLine:   location.Details.Start_line,
+				EndLine:     location.Details.End_line,
+				StartColumn: location.Details.Start_column,
+				EndColumn:   location.Details.End_column,
+			}
+		}
+		sarifLocations = append(sarifLocations, sarifLocation{PhysicalLocation: physicalLocation})
+	}
+	// code scanning requires a location on every result:
+	if len(sarifLocations) == 0 {
+		sarifLocations = append(sarifLocations, sarifLocation{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: alert.HTML_URL}}})
+	}
+	return sarifLocations
+}
+
+func sarifLevel(status string) string {
+	// active secrets are errors, secrets that could not be checked are warnings:
+	switch status {
+	case string(OutcomeActive):
+		return "error"
+	case string(OutcomeUnknown), "":
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+func secretTypeName(alert Alert) string {
+	if alert.Secret_type_display_name != "" {
+		return alert.Secret_type_display_name
+	}
+	return alert.Secret_type
+}
+
+func (r *sarifReportWriter) close() (err error) {
+	log := sarifLog{Version: sarifVersion, Schema: sarifSchema, Runs: []sarifRun{}}
+	sort.Strings(r.repositories)
+	for _, repository := range r.repositories {
+		results := r.results[repository]
+		log.Runs = append(log.Runs, sarifRun{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "gh-secret-scanning",
+				InformationURI: "https://github.com/CallMeGreg/gh-secret-scanning",
+				Rules:          r.rulesOf(results),
+			}},
+			VersionControlProvenance: []sarifVersionControlInfo{r.versionControlInfo(repository)},
+			Results:                  results,
+		})
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(r.filename, data, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintln(logOutput(), Blue("SARIF report generated: "+r.filename))
+	return nil
+}
+
+func (r *sarifReportWriter) rulesOf(results []sarifResult) (rules []sarifRule) {
+	for _, result := range results {
+		if !slices.ContainsFunc(rules, func(rule sarifRule) bool { return rule.ID == result.RuleID }) {
+			rules = append(rules, r.rules[result.RuleID])
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules
+}
+
+func (r *sarifReportWriter) versionControlInfo(repository string) sarifVersionControlInfo {
+	info := sarifVersionControlInfo{RepositoryURI: "https://" + host + "/" + repository}
+	// results are reported against the current commit of the default branch:
+	var commit struct {
+		Sha string `json:"sha"`
+	}
+	if _, _, err := callGitHubAPI(r.client, "repos/"+repository+"/commits/HEAD", &commit, GET); err != nil {
+		fmt.Fprintln(logOutput(), Yellow("WARNING: Unable to get the current commit of "+repository+": "+err.Error()))
+		return info
+	}
+	info.RevisionID = commit.Sha
+	return info
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import "testing"
+
+func TestSARIFResultLevel(t *testing.T) {
+	tests := []struct {
+		name           string
+		validity_check bool
+		github         string
+		outcome        Outcome
+		want           string
+	}{
+		{"alerts uses GitHub validity", false, "active", "", "error"},
+		{"alerts ignores verification", false, "inactive", OutcomeActive, "note"},
+		{"verified active", true, "unknown", OutcomeActive, "error"},
+		{"verified inactive", true, "active", OutcomeInactive, "note"},
+		{"could not be checked", true, "inactive", OutcomeUnknown, "warning"},
+		{"unsupported falls back to GitHub validity", true, "active", OutcomeUnsupported, "error"},
+		{"skipped falls back to GitHub validity", true, "inactive", OutcomeSkipped, "note"},
+		{"unsupported without GitHub validity", true, "", OutcomeUnsupported, "warning"},
+	}
+	for _, test := range tests {
+		writer := &sarifReportWriter{validity_check: test.validity_check}
+		alert := Alert{Number: 1, Secret_type: "x", Repository: Repository{Full_name: "o/r"}, Validity_github: test.github, Validity_outcome: test.outcome}
+		if got := writer.alertToResult(alert).Level; got != test.want {
+			t.Errorf("%s: level = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package cmd
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var snapshotsBucket = []byte("snapshots")
+
+// each snapshot is a bucket, keyed by snapshot ID, that holds its metadata and a bucket of its alerts:
+var snapshotMetadataKey = []byte("snapshot")
+var snapshotAlertsBucket = []byte("alerts")
+
+// number of snapshots kept in the state store, older ones are removed as new ones are saved:
+var keepSnapshots int
+
+// Snapshot is the alerts fetched by a single alerts or verify run, for comparison with other runs. Its
+// alerts are written to the state store page by page, as they are fetched, and only read back to be
+// compared. Secret values are never stored.
+type Snapshot struct {
+	Id          uint64    `json:"id"`
+	Host        string    `json:"host"`
+	Command     string    `json:"command"`
+	Query       []string  `json:"query"`
+	Targets     []Target  `json:"targets"`
+	Taken_at    time.Time `json:"taken_at"`
+	Alert_count int       `json:"alert_count"`
+	// a snapshot is only complete once its run has fetched every alert:
+	Complete bool `json:"complete"`
+
+	store *stateStore
+}
+
+type SnapshotAlert struct {
+	Repository  string `json:"repository"`
+	Number      int    `json:"number"`
+	Secret_type string `json:"secret_type"`
+	HTML_URL    string `json:"html_url"`
+	State       string `json:"state"`
+	Resolution  string `json:"resolution"`
+	Validity    string `json:"validity"`
+}
+
+// snapshotQuery is the alerts request of each source without the pagination cursor, so that only runs
+// that requested the same alerts, with the same filters, sort order and page size, are compared.
+func snapshotQuery(sources []alertSource) (query []string) {
+	for _, source := range sources {
+		path, rawQuery, _ := strings.Cut(source.requestPath, "?")
+		values, err := url.ParseQuery(rawQuery)
+		if err != nil {
+			query = append(query, source.requestPath)
+			continue
+		}
+		values.Del("after")
+		query = append(query, path+"?"+values.Encode())
+	}
+	slices.Sort(query)
+	return query
+}
+
+// beginSnapshot starts the snapshot of a run. A --sync run only fetches the alerts that changed, so its
+// snapshot starts as a copy of the previous snapshot of the same command and query. Snapshots left
+// incomplete by runs that stopped part way through are removed. A nil store takes no snapshot.
+func (s *stateStore) beginSnapshot(command string, targets []Target, sources []alertSource, now time.Time) (snapshot *Snapshot, err error) {
+	if s == nil {
+		return nil, nil
+	}
+	snapshot = &Snapshot{Host: host, Command: command, Query: snapshotQuery(sources), Targets: targets, Taken_at: now, store: s}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(snapshotsBucket)
+		var previous *Snapshot
+		var incomplete [][]byte
+		err := eachSnapshot(bucket, func(key []byte, metadata Snapshot) error {
+			if !metadata.Complete {
+				incomplete = append(incomplete, slices.Clone(key))
+			} else if previous == nil && metadata.Host == host && metadata.Command == command && slices.Equal(metadata.Query, snapshot.Query) {
+				previous = &metadata
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, key := range incomplete {
+			if err = deleteSnapshot(bucket, key); err != nil {
+				return err
+			}
+		}
+		if snapshot.Id, err = bucket.NextSequence(); err != nil {
+			return err
+		}
+		snapshotBucket, err := bucket.CreateBucket(snapshotKey(snapshot.Id))
+		if err != nil {
+			return err
+		}
+		alertsBucket, err := snapshotBucket.CreateBucket(snapshotAlertsBucket)
+		if err != nil {
+			return err
+		}
+		if syncOnly && previous != nil {
+			previousAlerts := bucket.Bucket(snapshotKey(previous.Id)).Bucket(snapshotAlertsBucket)
+			err = previousAlerts.ForEach(func(key []byte, data []byte) error {
+				snapshot.Alert_count++
+				return alertsBucket.Put(key, data)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return snapshot.putMetadata(snapshotBucket)
+	})
+	return snapshot, err
+}
+
+// add writes a page of alerts to the snapshot, replacing any earlier version of the same alerts.
+func (s *Snapshot) add(alerts []Alert) error {
+	if s == nil {
+		return nil
+	}
+	return s.store.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(snapshotsBucket).Bucket(snapshotKey(s.Id)).Bucket(snapshotAlertsBucket)
+		for _, alert := range alerts {
+			snapshotAlert := newSnapshotAlert(alert)
+			key := []byte(snapshotAlertKey(snapshotAlert))
+			if bucket.Get(key) == nil {
+				s.Alert_count++
+			}
+			data, err := json.Marshal(snapshotAlert)
+			if err != nil {
+				return err
+			}
+			if err = bucket.Put(key, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// finish marks the snapshot complete, so that it can be compared, and removes the oldest snapshots
+// beyond --keep-snapshots.
+func (s *Snapshot) finish() error {
+	if s == nil {
+		return nil
+	}
+	s.Complete = true
+	return s.store.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(snapshotsBucket)
+		if err := s.putMetadata(bucket.Bucket(snapshotKey(s.Id))); err != nil {
+			return err
+		}
+		if keepSnapshots == 0 {
+			return nil
+		}
+		var keys [][]byte
+		cursor := bucket.Cursor()
+		for key, _ := cursor.First(); key != nil; key, _ = cursor.Next() {
+			keys = append(keys, slices.Clone(key))
+		}
+		for _, key := range keys[:max(len(keys)-keepSnapshots, 0)] {
+			if err := deleteSnapshot(bucket, key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func deleteSnapshot(bucket *bolt.Bucket, key []byte) error {
+	if bucket.Bucket(key) == nil {
+		return bucket.Delete(key)
+	}
+	return bucket.DeleteBucket(key)
+}
+
+func (s *Snapshot) putMetadata(bucket *bolt.Bucket) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(snapshotMetadataKey, data)
+}
+
+func newSnapshotAlert(alert Alert) SnapshotAlert {
+	return SnapshotAlert{
+		Repository:  alert.Repository.Full_name,
+		Number:      alert.Number,
+		Secret_type: alert.Secret_type,
+		HTML_URL:    alert.HTML_URL,
+		State:       alert.State,
+		Resolution:  alert.Resolution,
+		Validity:    alertValidity(alert),
+	}
+}
+
+func alertValidity(alert Alert) string {
+	// a verification by this tool is more recent than the validity reported by GitHub:
+	switch alert.Validity_outcome {
+	case OutcomeActive, OutcomeInactive:
+		return string(alert.Validity_outcome)
+	}
+	return alert.Validity_github
+}
+
+func snapshotKey(id uint64) []byte {
+	// big endian, so that the bucket iterates snapshots from oldest to newest:
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+// eachSnapshot calls fn with the metadata of every snapshot, newest first.
+func eachSnapshot(bucket *bolt.Bucket, fn func(key []byte, metadata Snapshot) error) error {
+	cursor := bucket.Cursor()
+	for key, _ := cursor.Last(); key != nil; key, _ = cursor.Prev() {
+		// an entry that isn't a bucket, e.g. written by an earlier version, is treated as incomplete:
+		var metadata Snapshot
+		if snapshotBucket := bucket.Bucket(key); snapshotBucket != nil {
+			if err := json.Unmarshal(snapshotBucket.Get(snapshotMetadataKey), &metadata); err != nil {
+				return fmt.Errorf("corrupt snapshot %d: %w", binary.BigEndian.Uint64(key), err)
+			}
+		}
+		if err := fn(key, metadata); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshots returns every complete snapshot taken on the current host, newest first.
+func (s *stateStore) snapshots() (snapshots []Snapshot, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		return eachSnapshot(tx.Bucket(snapshotsBucket), func(key []byte, metadata Snapshot) error {
+			if metadata.Complete && metadata.Host == host {
+				snapshots = append(snapshots, metadata)
+			}
+			return nil
+		})
+	})
+	return snapshots, err
+}
+
+// latestSnapshots returns up to count of the newest snapshots taken by the same command and query.
+func (s *stateStore) latestSnapshots(command string, query []string, count int) (latest []Snapshot, err error) {
+	snapshots, err := s.snapshots()
+	if err != nil {
+		return nil, err
+	}
+	for _, snapshot := range snapshots {
+		if len(latest) == count {
+			break
+		}
+		if snapshot.Command == command && slices.Equal(snapshot.Query, query) {
+			latest = append(latest, snapshot)
+		}
+	}
+	return latest, nil
+}
+
+// latestTargetSnapshots returns up to count of the newest snapshots comparable with the newest snapshot
+// taken of exactly the given targets, i.e. taken by the same command and query.
+func (s *stateStore) latestTargetSnapshots(targets []Target, count int) (latest []Snapshot, err error) {
+	snapshots, err := s.snapshots()
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(snapshots, func(snapshot Snapshot) bool { return sameTargets(snapshot.Targets, targets) })
+	if i < 0 {
+		return nil, nil
+	}
+	return s.latestSnapshots(snapshots[i].Command, snapshots[i].Query, count)
+}
+
+func sameTargets(a []Target, b []Target) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, target := range a {
+		if !slices.Contains(b, target) {
+			return false
+		}
+	}
+	return true
+}
+
+// snapshot returns the metadata of a complete snapshot by id.
+func (s *stateStore) snapshot(id uint64) (snapshot Snapshot, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(snapshotsBucket).Bucket(snapshotKey(id))
+		if bucket == nil {
+			return fmt.Errorf("there is no snapshot %d", id)
+		}
+		if err := json.Unmarshal(bucket.Get(snapshotMetadataKey), &snapshot); err != nil {
+			return fmt.Errorf("corrupt snapshot %d: %w", id, err)
+		}
+		if !snapshot.Complete {
+			return fmt.Errorf("snapshot %d is incomplete, its run stopped part way through", id)
+		}
+		return nil
+	})
+	return snapshot, err
+}
+
+// snapshotAlerts reads the alerts of a snapshot.
+func (s *stateStore) snapshotAlerts(snapshot Snapshot) (alerts []SnapshotAlert, err error) {
+	alerts = make([]SnapshotAlert, 0, snapshot.Alert_count)
+	err = s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(snapshotsBucket).Bucket(snapshotKey(snapshot.Id)).Bucket(snapshotAlertsBucket)
+		return bucket.ForEach(func(key []byte, data []byte) error {
+			var alert SnapshotAlert
+			if err := json.Unmarshal(data, &alert); err != nil {
+				return fmt.Errorf("corrupt snapshot %d: %w", snapshot.Id, err)
+			}
+			alerts = append(alerts, alert)
+			return nil
+		})
+	})
+	return alerts, err
+}
@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/config"
+	bolt "go.etcd.io/bbolt"
+)
+
+var stateFile string
+var noState bool
+
+var alertsBucket = []byte("alerts")
+var syncBucket = []byte("sync")
+
+// AlertRecord is the history of a single alert, as seen across runs. Secret values are never stored.
+type AlertRecord struct {
+	Host          string               `json:"host"`
+	Repository    string               `json:"repository"`
+	Number        int                  `json:"number"`
+	Secret_type   string               `json:"secret_type"`
+	HTML_URL      string               `json:"html_url"`
+	State         string               `json:"state"`
+	Resolution    string               `json:"resolution"`
+	Created_at    string               `json:"created_at"`
+	Updated_at    string               `json:"updated_at"`
+	Resolved_at   string               `json:"resolved_at"`
+	First_seen    time.Time            `json:"first_seen"`
+	Last_seen     time.Time            `json:"last_seen"`
+	First_active  *time.Time           `json:"first_active,omitempty"`
+	Revoked_at    *time.Time           `json:"revoked_at,omitempty"`
+	Last_verified *time.Time           `json:"last_verified,omitempty"`
+	Verifications []VerificationRecord `json:"verifications,omitempty"`
+}
+
+// VerificationRecord is a verification result. Only changes are recorded, so each record marks the time
+// the outcome was first seen.
+type VerificationRecord struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Outcome     Outcome   `json:"outcome"`
+	Status_code string    `json:"status_code,omitempty"`
+	Endpoint    string    `json:"endpoint,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+}
+
+// stateStore is the local history of alerts and their verification outcomes, kept in an embedded bbolt
+// database so that runs can answer questions about earlier runs. A nil store records nothing.
+type stateStore struct {
+	db *bolt.DB
+}
+
+func defaultStateFile() string {
+	return filepath.Join(config.StateDir(), "gh-secret-scanning", "state.db")
+}
+
+func openStateStore() (store *stateStore, err error) {
+	if noState {
+		return nil, nil
+	}
+	path := stateFile
+	if path == "" {
+		path = defaultStateFile()
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	// only one run can have the store open at a time:
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 10 * time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("the state store %s is in use by another run, or use --no-state", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to open the state store %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{alertsBucket, syncBucket, snapshotsBucket, issuesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &stateStore{db: db}, nil
+}
+
+func (s *stateStore) close() error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+func alertRecordKey(host string, repository string, number int) []byte {
+	// e.g. "github.com/octo-org/octo-repo/42":
+	return []byte(host + "/" + repository + "/" + strconv.Itoa(number))
+}
+
+// record merges a page of alerts, and their verification outcomes, into the history.
+func (s *stateStore) record(alerts []Alert, now time.Time) error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(alertsBucket)
+		for _, alert := range alerts {
+			key := alertRecordKey(host, alert.Repository.Full_name, alert.Number)
+			var record AlertRecord
+			if data := bucket.Get(key); data != nil {
+				if err := json.Unmarshal(data, &record); err != nil {
+					return fmt.Errorf("corrupt state for %s: %w", key, err)
+				}
+			}
+			record.update(alert, now)
+			data, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+			if err = bucket.Put(key, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *AlertRecord) update(alert Alert, now time.Time) {
+	r.Host = host
+	r.Repository = alert.Repository.Full_name
+	r.Number = alert.Number
+	r.Secret_type = alert.Secret_type
+	r.HTML_URL = alert.HTML_URL
+	r.State = alert.State
+	r.Resolution = alert.Resolution
+	r.Created_at = alert.Created_at
+	r.Updated_at = alert.Updated_at
+	r.Resolved_at = alert.Resolved_at
+	if r.First_seen.IsZero() {
+		r.First_seen = now
+	}
+	r.Last_seen = now
+
+	// an alert resolved as revoked counts as revoked from the time it was resolved:
+	if alert.Resolution == "revoked" && r.Revoked_at == nil {
+		if resolvedAt, err := time.Parse(time.RFC3339, alert.Resolved_at); err == nil {
+			r.Revoked_at = &resolvedAt
+		}
+	}
+
+	// only secrets that were actually checked say anything about their validity:
+	switch alert.Validity_outcome {
+	case OutcomeActive, OutcomeInactive, OutcomeUnknown:
+	default:
+		return
+	}
+	r.Last_verified = &now
+	last := r.lastVerification()
+	if last == nil || last.Outcome != alert.Validity_outcome || last.Status_code != alert.Validity_response_code {
+		r.Verifications = append(r.Verifications, VerificationRecord{
+			Timestamp:   now,
+			Outcome:     alert.Validity_outcome,
+			Status_code: alert.Validity_response_code,
+			Endpoint:    alert.Validity_endpoint,
+			Reason:      alert.Validity_reason,
+		})
+	}
+	switch alert.Validity_outcome {
+	case OutcomeActive:
+		if r.First_active == nil {
+			r.First_active = &now
+		}
+		// a secret that is active again hasn't been revoked after all:
+		r.Revoked_at = nil
+	case OutcomeInactive:
+		// a secret is revoked when it goes from active to inactive:
+		if r.First_active != nil && r.Revoked_at == nil {
+			r.Revoked_at = &now
+		}
+	}
+}
+
+func (r *AlertRecord) lastVerification() *VerificationRecord {
+	if len(r.Verifications) == 0 {
+		return nil
+	}
+	return &r.Verifications[len(r.Verifications)-1]
+}
+
+// timeToRevoke is how long the secret stayed exposed, from when GitHub detected it until it was revoked.
+func (r *AlertRecord) timeToRevoke() (time.Duration, bool) {
+	if r.Revoked_at == nil {
+		return 0, false
+	}
+	createdAt, err := time.Parse(time.RFC3339, r.Created_at)
+	if err != nil {
+		return 0, false
+	}
+	return max(r.Revoked_at.Sub(createdAt), 0), true
+}
+
+// records returns the history of every alert of the targets on the current host.
+func (s *stateStore) records(targets []Target) (records []AlertRecord, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(alertsBucket).Cursor()
+		prefix := []byte(host + "/")
+		for key, data := cursor.Seek(prefix); key != nil && strings.HasPrefix(string(key), string(prefix)); key, data = cursor.Next() {
+			var record AlertRecord
+			if err := json.Unmarshal(data, &record); err != nil {
+				return fmt.Errorf("corrupt state for %s: %w", key, err)
+			}
+			if repositoryMatchesTargets(record.Repository, targets) {
+				records = append(records, record)
+			}
+		}
+		return nil
+	})
+	return records, err
+}
+
+func repositoryMatchesTargets(repository string, targets []Target) bool {
+	for _, target := range targets {
+		switch target.Scope {
+		// the store doesn't know which organizations belong to an enterprise, so it matches every repository:
+		case "enterprise":
+			return true
+		case "organization":
+			if strings.HasPrefix(repository, target.Name+"/") {
+				return true
+			}
+		case "repository":
+			if repository == target.Name {
+				return true
+			}
+		}
+	}
+	return false
+}
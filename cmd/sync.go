@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var syncOnly bool
+
+// syncCursor is how far a previous --sync run got through the alerts of a source: every alert updated
+// before Updated_at has already been fetched. Alerts are requested most recently updated first, so
+// fetching stops as soon as it reaches an alert that hasn't changed since.
+type syncCursor struct {
+	Updated_at string    `json:"updated_at"`
+	Synced_at  time.Time `json:"synced_at"`
+
+	store *stateStore
+	key   []byte
+	// the most recently updated alert fetched by this run, and whether every changed alert was fetched:
+	latest   string
+	complete bool
+}
+
+func validateSyncFlags() error {
+	if !syncOnly {
+		return nil
+	}
+	if noState {
+		return errors.New("--sync requires the local history, it can't be combined with --no-state")
+	}
+	if resume {
+		return errors.New("--sync can't be combined with --resume")
+	}
+	if alertSort != "" || alertDirection != "" {
+		return errors.New("--sync sorts alerts by updated time, it can't be combined with --sort or --direction")
+	}
+	return nil
+}
+
+func setSyncParameters(values url.Values) {
+	// most recently updated first, so that the alerts already synced are all at the end:
+	values.Set("sort", "updated")
+	values.Set("direction", "desc")
+}
+
+// loadSyncCursors attaches the cursor saved by the previous --sync run to each source. A source that
+// hasn't been synced before fetches every alert.
+func (s *stateStore) loadSyncCursors(sources []alertSource) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(syncBucket)
+		for i := range sources {
+			// the cursor only applies to the same query against the same host:
+			key := []byte(host + " " + sources[i].requestPath)
+			cursor := &syncCursor{store: s, key: key}
+			if data := bucket.Get(key); data != nil {
+				if err := json.Unmarshal(data, cursor); err != nil {
+					return fmt.Errorf("corrupt state for %s: %w", key, err)
+				}
+			}
+			sources[i].sync = cursor
+		}
+		return nil
+	})
+}
+
+// saveSyncCursors moves the cursor of each source on to the most recently updated alert of this run.
+// Sources that stopped before reaching the alerts already synced, e.g. at the --limit, keep their
+// cursor so that the next run fetches the alerts that were skipped.
+func (s *stateStore) saveSyncCursors(sources []alertSource, now time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(syncBucket)
+		for _, source := range sources {
+			cursor := source.sync
+			if cursor == nil || !cursor.complete {
+				continue
+			}
+			if cursor.latest > cursor.Updated_at {
+				cursor.Updated_at = cursor.latest
+			}
+			cursor.Synced_at = now
+			data, err := json.Marshal(cursor)
+			if err != nil {
+				return err
+			}
+			if err = bucket.Put(cursor.key, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// changed returns the alerts of a page that were updated since the previous sync, and whether the page
+// reached the alerts already synced.
+func (c *syncCursor) changed(alerts []Alert) (changed []Alert, reached bool, err error) {
+	if len(alerts) > 0 && alerts[0].Updated_at > c.latest {
+		c.latest = alerts[0].Updated_at
+	}
+	if c.Updated_at == "" {
+		return alerts, false, nil
+	}
+	for _, alert := range alerts {
+		// timestamps are RFC 3339 in UTC, so they compare as strings:
+		if alert.Updated_at < c.Updated_at {
+			return changed, true, nil
+		}
+		// an alert updated in the same second as the cursor may have changed after the previous sync:
+		if alert.Updated_at == c.Updated_at {
+			seen, err := c.store.seen(alert)
+			if err != nil {
+				return nil, false, err
+			}
+			if seen {
+				continue
+			}
+		}
+		changed = append(changed, alert)
+	}
+	return changed, false, nil
+}
+
+// seen reports whether the history already has the alert as of its last update.
+func (s *stateStore) seen(alert Alert) (seen bool, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(alertsBucket).Get(alertRecordKey(host, alert.Repository.Full_name, alert.Number))
+		if data == nil {
+			return nil
+		}
+		var record AlertRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		seen = record.Updated_at == alert.Updated_at
+		return nil
+	})
+	return seen, err
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSyncCursorChanged(t *testing.T) {
+	store := newTestStateStore(t)
+	alert := func(number int, updatedAt string) Alert {
+		return Alert{Number: number, Updated_at: updatedAt, Repository: Repository{Full_name: "o/r"}}
+	}
+	// alert 3 was recorded by the previous sync as of its last update, alert 4 changed in the same second:
+	if err := store.record([]Alert{alert(3, "2026-01-03T00:00:00Z"), alert(4, "2026-01-02T23:00:00Z")}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		updatedAt   string
+		page        []Alert
+		wantChanged []int
+		wantReached bool
+		wantLatest  string
+	}{
+		{
+			name:        "first sync",
+			page:        []Alert{alert(1, "2026-01-05T00:00:00Z"), alert(2, "2026-01-04T00:00:00Z")},
+			wantChanged: []int{1, 2},
+			wantLatest:  "2026-01-05T00:00:00Z",
+		},
+		{
+			name:        "every alert changed",
+			updatedAt:   "2026-01-01T00:00:00Z",
+			page:        []Alert{alert(1, "2026-01-05T00:00:00Z"), alert(2, "2026-01-04T00:00:00Z")},
+			wantChanged: []int{1, 2},
+			wantLatest:  "2026-01-05T00:00:00Z",
+		},
+		{
+			name:        "stops at the alerts already synced",
+			updatedAt:   "2026-01-04T00:00:00Z",
+			page:        []Alert{alert(1, "2026-01-05T00:00:00Z"), alert(2, "2026-01-03T00:00:00Z"), alert(5, "2026-01-05T00:00:00Z")},
+			wantChanged: []int{1},
+			wantReached: true,
+			wantLatest:  "2026-01-05T00:00:00Z",
+		},
+		{
+			name:        "nothing changed",
+			updatedAt:   "2026-01-05T00:00:00Z",
+			page:        []Alert{alert(1, "2026-01-04T00:00:00Z")},
+			wantReached: true,
+			wantLatest:  "2026-01-04T00:00:00Z",
+		},
+		{
+			name:        "alerts updated in the same second as the cursor",
+			updatedAt:   "2026-01-03T00:00:00Z",
+			page:        []Alert{alert(3, "2026-01-03T00:00:00Z"), alert(4, "2026-01-03T00:00:00Z"), alert(2, "2026-01-02T00:00:00Z")},
+			wantChanged: []int{4},
+			wantReached: true,
+			wantLatest:  "2026-01-03T00:00:00Z",
+		},
+		{
+			name:       "empty page",
+			updatedAt:  "2026-01-03T00:00:00Z",
+			wantLatest: "",
+		},
+	}
+	for _, test := range tests {
+		cursor := &syncCursor{Updated_at: test.updatedAt, store: store}
+		changed, reached, err := cursor.changed(test.page)
+		if err != nil {
+			t.Errorf("%s: changed() failed: %v", test.name, err)
+			continue
+		}
+		var numbers []int
+		for _, alert := range changed {
+			numbers = append(numbers, alert.Number)
+		}
+		if !slices.Equal(numbers, test.wantChanged) || reached != test.wantReached {
+			t.Errorf("%s: changed() = %v, %t, want %v, %t", test.name, numbers, reached, test.wantChanged, test.wantReached)
+		}
+		if cursor.latest != test.wantLatest {
+			t.Errorf("%s: latest = %q, want %q", test.name, cursor.latest, test.wantLatest)
+		}
+	}
+}
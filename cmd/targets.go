@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var targetEnterprises []string
+var targetOrganizations []string
+var targetRepositories []string
+var targetsFile string
+
+// Target is an enterprise, organization, or repository to process alerts for.
+type Target struct {
+	Scope string `json:"scope"`
+	Name  string `json:"name"`
+}
+
+var repoPattern = regexp.MustCompile(`^[^/]+/[^/]+$`)
+
+// flags that select the targets of a subcommand:
+var targetFlags = []string{"enterprise", "organization", "repository", "targets-file"}
+
+func hasTargetFlags(cmd *cobra.Command) bool {
+	return slices.ContainsFunc(targetFlags, func(name string) bool { return cmd.Flags().Changed(name) })
+}
+
+func targetsRequired(cmd *cobra.Command, args []string) bool {
+	// diff only needs targets to find the latest snapshots, not to list snapshots or to compare two given runs:
+	if cmd == diffCmd {
+		return len(args) == 0 && !listSnapshots
+	}
+	return true
+}
+
+// getTargets returns every target specified by the --enterprise, --organization and --repository flags,
+// and the --targets-file, without duplicates.
+func getTargets() (targets []Target, err error) {
+	for _, name := range targetEnterprises {
+		targets = append(targets, Target{Scope: "enterprise", Name: name})
+	}
+	for _, name := range targetOrganizations {
+		targets = append(targets, Target{Scope: "organization", Name: name})
+	}
+	for _, name := range targetRepositories {
+		targets = append(targets, Target{Scope: "repository", Name: name})
+	}
+	if targetsFile != "" {
+		fileTargets, err := loadTargetsFile(targetsFile)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, fileTargets...)
+	}
+	var uniqueTargets []Target
+	for _, target := range targets {
+		if strings.TrimSpace(target.Name) == "" {
+			return nil, errors.New(target.Scope + " name must not be empty")
+		}
+		if target.Scope == "repository" && !repoPattern.MatchString(target.Name) {
+			return nil, errors.New("repository must follow the format 'owner/repository': " + target.Name)
+		}
+		if !slices.Contains(uniqueTargets, target) {
+			uniqueTargets = append(uniqueTargets, target)
+		}
+	}
+	if len(uniqueTargets) == 0 {
+		return nil, errors.New("no enterprise, organization, or repository to target")
+	}
+	return uniqueTargets, nil
+}
+
+// getAlertTargets returns the targets to get alerts for, checking that secret scanning is enabled for
+// each targeted repository.
+func getAlertTargets() (targets []Target, err error) {
+	targets, err = getTargets()
+	if err != nil {
+		return nil, err
+	}
+	for _, target := range targets {
+		if target.Scope != "repository" {
+			continue
+		}
+		secretScanningEnabled, err := checkSecretScanningSetting(target.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !secretScanningEnabled {
+			return nil, errors.New("Secret scanning is not enabled for the repository: " + target.Name)
+		}
+	}
+	return targets, nil
+}
+
+func loadTargetsFile(path string) (targets []Target, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read targets file: %w", err)
+	}
+	defer file.Close()
+	// one target per line: "owner/repository", "organization" or "enterprise:slug", with # comments:
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "enterprise:"):
+			name := strings.TrimSpace(strings.TrimPrefix(line, "enterprise:"))
+			if name == "" {
+				return nil, fmt.Errorf("%s:%d: empty target name %q", path, lineNumber, line)
+			}
+			targets = append(targets, Target{Scope: "enterprise", Name: name})
+		case strings.Contains(line, "/"):
+			targets = append(targets, Target{Scope: "repository", Name: line})
+		case strings.ContainsAny(line, " \t:"):
+			return nil, fmt.Errorf("%s:%d: invalid target %q", path, lineNumber, line)
+		default:
+			targets = append(targets, Target{Scope: "organization", Name: line})
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+func reportScope(targets []Target) string {
+	// reports are named after the scope of the target, e.g. SecretScanningReport-organization-...:
+	if len(targets) == 1 {
+		return targets[0].Scope
+	}
+	return "multiple"
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadTargetsFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []Target
+		wantErr string
+	}{
+		{
+			name:    "targets",
+			content: "# targets\nenterprise: e\no # organization\no/r\n\n",
+			want:    []Target{{Scope: "enterprise", Name: "e"}, {Scope: "organization", Name: "o"}, {Scope: "repository", Name: "o/r"}},
+		},
+		{
+			name:    "invalid target",
+			content: "o\nteam: t\n",
+			wantErr: `targets.txt:2: invalid target "team: t"`,
+		},
+		{
+			name:    "enterprise without a slug",
+			content: "o\nenterprise:\n",
+			wantErr: `targets.txt:2: empty target name "enterprise:"`,
+		},
+		{
+			name:    "enterprise with a blank slug",
+			content: "enterprise:   # no slug\n",
+			wantErr: `targets.txt:1: empty target name "enterprise:"`,
+		},
+	}
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), "targets.txt")
+		if err := os.WriteFile(path, []byte(test.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		got, err := loadTargetsFile(path)
+		if test.wantErr != "" {
+			if want := filepath.Dir(path) + string(filepath.Separator) + test.wantErr; err == nil || err.Error() != want {
+				t.Errorf("%s: loadTargetsFile() error = %v, want %q", test.name, err, want)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: loadTargetsFile() failed: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: loadTargetsFile() = %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestGetTargetsRejectsEmptyNames(t *testing.T) {
+	targetEnterprises = []string{" "}
+	defer func() { targetEnterprises = nil }()
+	if _, err := getTargets(); err == nil || err.Error() != "enterprise name must not be empty" {
+		t.Errorf("getTargets() error = %v, want %q", err, "enterprise name must not be empty")
+	}
+}
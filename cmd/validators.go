@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// Outcome is the verdict of checking a secret. Only OutcomeActive and OutcomeInactive say
+// anything about the secret itself, the other outcomes mean that it could not be checked.
+type Outcome string
+
+const (
+	OutcomeActive      Outcome = "active"
+	OutcomeInactive    Outcome = "inactive"
+	OutcomeUnknown     Outcome = "unknown"
+	OutcomeUnsupported Outcome = "unsupported"
+	OutcomeSkipped     Outcome = "skipped"
+)
+
+// Result is the outcome of checking a single secret against its provider.
+type Result struct {
+	Outcome    Outcome
+	Reason     string
+	StatusCode int
+	Endpoint   string
+}
+
+// Validator confirms whether the secret in an alert is still active. Implementations are
+// registered per provider and secret type with registerValidator.
+type Validator interface {
+	Validate(ctx context.Context, alert Alert) (Result, error)
+}
+
+func registerValidator(provider string, secretType string, validator Validator) {
+	provider = strings.ToLower(provider)
+	if _, ok := SupportedProviders[provider]; !ok {
+		SupportedProviders[provider] = map[string]Validator{}
+	}
+	SupportedProviders[provider][secretType] = validator
+}
+
+func lookupValidator(secretType string) (provider string, validator Validator, ok bool) {
+	// prefer the provider named by the secret type prefix, e.g. "slack" for "slack_api_token":
+	provider = strings.Split(secretType, "_")[0]
+	if validator, ok = SupportedProviders[provider][secretType]; ok {
+		return provider, validator, true
+	}
+	// custom providers don't have to follow that naming convention:
+	for provider, secretTypes := range SupportedProviders {
+		if validator, ok = secretTypes[secretType]; ok {
+			return provider, validator, true
+		}
+	}
+	return "", nil, false
+}
+
+// HTTPValidator sends a single request authenticated with the secret and checks the response
+// status code and, optionally, a key in the JSON response body. Requests are paced by the
+// secret type's own rate limiter if it has a rate_limit, otherwise by the provider's shared one,
+// and throttled responses are retried.
+type HTTPValidator struct {
+	ValidatorConfig
+	limiter *tokenBucket
+}
+
+func newHTTPValidator(provider string, validatorConfig ValidatorConfig) HTTPValidator {
+	limiter := providerLimiter(strings.ToLower(provider))
+	if validatorConfig.RateLimit != nil {
+		limiter = &tokenBucket{}
+		limiter.setLimit(*validatorConfig.RateLimit)
+	}
+	return HTTPValidator{ValidatorConfig: validatorConfig, limiter: limiter}
+}
+
+func (v HTTPValidator) Validate(ctx context.Context, alert Alert) (result Result, err error) {
+	result.Endpoint = v.ValidationEndpoint
+	var response *http.Response
+	for attempt := 0; ; attempt++ {
+		if err = v.limiter.Wait(ctx); err != nil {
+			return result, err
+		}
+		response, err = sendValidationRequest(ctx, v.HttpMethod, v.ValidationEndpoint, v.ContentType, v.Headers, alert.Secret)
+		if err != nil {
+			return result, err
+		}
+		result.StatusCode = response.StatusCode
+		wait, throttled := throttleWait(response.StatusCode, response.Header, attempt)
+		if !throttled {
+			break
+		}
+		response.Body.Close()
+		// a throttled check says nothing about the secret, so never report it as inactive:
+		if attempt >= maxThrottleRetries {
+			return result, fmt.Errorf("rate limited by %s after %d retries", v.ValidationEndpoint, attempt)
+		}
+		if verbose {
+			fmt.Fprintln(logOutput(), Gray("Rate limited by "+v.ValidationEndpoint+", retrying in "+wait.String()))
+		}
+		if err = sleepContext(ctx, wait); err != nil {
+			return result, err
+		}
+	}
+	defer response.Body.Close()
+	result.Outcome, result.Reason = v.evaluateResponse(response)
+	return result, nil
+}
+
+func (v HTTPValidator) evaluateResponse(response *http.Response) (Outcome, string) {
+	switch {
+	case response.StatusCode >= http.StatusInternalServerError:
+		return OutcomeUnknown, fmt.Sprintf("provider returned %d", response.StatusCode)
+	case response.StatusCode == http.StatusUnauthorized:
+		return OutcomeInactive, fmt.Sprintf("provider rejected the secret with %d", response.StatusCode)
+	// a live secret can be forbidden too, e.g. by an IP allow list, SSO enforcement or missing scopes:
+	case response.StatusCode == http.StatusForbidden:
+		return OutcomeUnknown, fmt.Sprintf("provider returned %d, the secret may be active but not allowed to make the request", response.StatusCode)
+	case response.StatusCode != http.StatusOK:
+		return OutcomeUnknown, fmt.Sprintf("unexpected %d response", response.StatusCode)
+	case v.ExpectedBodyKey == "":
+		return OutcomeActive, "provider accepted the secret"
+	}
+	matched, actual, err := checkForExpectedBody(response, v.ExpectedBodyKey, v.ExpectedBodyValue)
+	if err != nil {
+		return OutcomeUnknown, err.Error()
+	}
+	if !matched {
+		return OutcomeInactive, fmt.Sprintf("%s was %q, expected %q", v.ExpectedBodyKey, actual, v.ExpectedBodyValue)
+	}
+	return OutcomeActive, fmt.Sprintf("%s was %q", v.ExpectedBodyKey, actual)
+}
+
+// GitHubTokenValidator checks a token against github.com and, when a GHES host is targeted,
+// falls back to that server's API.
+type GitHubTokenValidator struct {
+	HTTPValidator
+}
+
+func (v GitHubTokenValidator) Validate(ctx context.Context, alert Alert) (result Result, err error) {
+	result, err = v.HTTPValidator.Validate(ctx, alert)
+	if err != nil || result.Outcome == OutcomeActive || host == "github.com" {
+		return result, err
+	}
+	// also confirm validity with the provided GitHub Enterprise Server API:
+	enterpriseServerValidator := v.HTTPValidator
+	enterpriseServerValidator.ValidationEndpoint = "https://" + host + "/api/v3/"
+	return enterpriseServerValidator.Validate(ctx, alert)
+}
+
+func sendValidationRequest(ctx context.Context, method string, endpoint string, contentType string, headers map[string]string, secret string) (response *http.Response, err error) {
+	// create a new client for the validation request:
+	var opts api.ClientOptions
+	opts.AuthToken = secret
+	opts.Headers = map[string]string{
+		"User-Agent": "gh-secret-scanning",
+	}
+	client, err := api.NewHTTPClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	// the client only attaches its auth token for GitHub hosts, so set it explicitly for custom endpoints:
+	req.Header.Set("Authorization", "Bearer "+secret)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "gh-secret-scanning")
+	// custom headers from a providers file may reference the secret, e.g. "Authorization: token {{secret}}":
+	for name, value := range headers {
+		req.Header.Set(name, strings.ReplaceAll(value, secretPlaceholder, secret))
+	}
+	response, err = client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to send %s request to %s: %w", method, endpoint, err)
+	}
+	return response, nil
+}
+
+func checkForExpectedBody(response *http.Response, expected_body_key string, expected_body_value string) (matched bool, body_value string, err error) {
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return false, "", fmt.Errorf("unable to read response body from %s: %w", response.Request.URL, err)
+	}
+	var response_body map[string]interface{}
+	err = json.Unmarshal(body, &response_body)
+	if err != nil {
+		return false, "", fmt.Errorf("unable to unmarshal response body from %s: %w", response.Request.URL, err)
+	}
+	value, ok := response_body[expected_body_key]
+	if !ok {
+		return false, "", nil
+	}
+	// compare the string form so that booleans and numbers can be matched, e.g. "true":
+	body_value = fmt.Sprint(value)
+	return body_value == expected_body_value, body_value, nil
+}
@@ -1,132 +1,206 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
-	"math"
+	"maps"
 	"net/url"
-	"strconv"
+	"slices"
+	"time"
 
 	"github.com/cli/go-gh/v2/pkg/api"
 	"github.com/spf13/cobra"
 )
 
 var createIssues bool
+var concurrency int
+var resolveInactive bool
+var dryRun bool
+var assumeYes bool
 
 func init() {
 	verifyCmd.PersistentFlags().BoolVarP(&createIssues, "create-issues", "i", false, "Create issues in repos that contain valid secret alerts")
+	verifyCmd.PersistentFlags().IntVar(&concurrency, "concurrency", 1, "Number of secrets to verify in parallel")
+	verifyCmd.PersistentFlags().BoolVar(&resolveInactive, "resolve-inactive", false, "Resolve open alerts as revoked when their secret is confirmed inactive")
+	verifyCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Preview the alerts that would be resolved without changing them")
+	verifyCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "Skip the confirmation prompt")
+	verifyCmd.PersistentFlags().StringVar(&issueTemplateFile, "issue-template", "", "Path to a Go template file defining the issue \"title\" and/or \"body\"")
+	verifyCmd.PersistentFlags().StringSliceVar(&issueLabels, "issue-label", nil, "Add labels to created issues")
+	verifyCmd.PersistentFlags().StringSliceVar(&issueAssignees, "issue-assignee", nil, "Assign people to created issues by their login")
+	verifyCmd.PersistentFlags().StringVar(&issueMilestone, "issue-milestone", "", "Add created issues to a milestone by name")
+	verifyCmd.PersistentFlags().StringVar(&issueProject, "issue-project", "", "Add created issues to a project by title")
+	verifyCmd.PersistentFlags().BoolVar(&notifyCodeOwners, "code-owners", false, "Mention the CODEOWNERS of files containing active secrets in created issues")
+	verifyCmd.PersistentFlags().BoolVar(&assignCodeOwners, "assign-code-owners", false, "Also assign individual CODEOWNERS to created issues (implies --code-owners)")
+	addAlertFilterFlags(verifyCmd)
 }
 
 var verifyCmd = &cobra.Command{
 	Use:   "verify [flags]",
 	Short: "Verify alerts for an enterprise, organization, or repository",
+	PreRunE: func(cmd *cobra.Command, args []string) (err error) {
+		if concurrency < 1 {
+			return fmt.Errorf("--concurrency must be at least 1")
+		}
+		if dryRun && !resolveInactive {
+			return fmt.Errorf("--dry-run can only be used with --resolve-inactive")
+		}
+		if assignCodeOwners {
+			notifyCodeOwners = true
+		}
+		// parse the issue template up front, so that mistakes are reported before any alerts are fetched:
+		if createIssues {
+			return loadIssueTemplate(issueTemplateFile)
+		}
+		return nil
+	},
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		return runVerify(cmd, args)
 	},
 }
 
 func runVerify(cmd *cobra.Command, args []string) (err error) {
-	// set scope & target based on the flag that was used:
-	scope, target, err := getScopeAndTarget()
+	// set the targets based on the flags that were used:
+	targets, err := getAlertTargets()
 	if err != nil {
 		return err
 	}
 
-	// set the API URL based on the target:
-	requestPath, err := createGitHubSecretAlertsAPIPath(scope, target)
+	opts := setOptions()
+	client, err := api.NewRESTClient(opts)
 	if err != nil {
 		return err
 	}
 
-	// update the URL to include query parameters based on specified flags:
-	parsedURL, err := url.Parse(requestPath)
-	if err != nil {
-		return err
-	}
-	values := parsedURL.Query()
-	var per_page string
-	if limit < 100 {
-		per_page = strconv.Itoa(limit)
-	} else {
-		per_page = "100"
-	}
-	per_page_int, err := strconv.Atoi(per_page)
-	if err != nil {
-		return err
-	}
-	values.Set("per_page", per_page)
+	// set the query parameters based on specified flags:
+	values := url.Values{}
+	setPaginationParameters(values)
 	// if provider was specified, filter results for just that provider. Otherwise, target all supported providers:
 	secret_type := getSecretTypeParameter()
 	if secret_type != "all" {
 		values.Set("secret_type", secret_type)
-		parsedURL.RawQuery = values.Encode()
+	}
+	// add the alert filters specified by flags:
+	if err = setAlertFilterParameters(client, values); err != nil {
+		return err
 	}
 
-	// update the request path
-	requestPath = parsedURL.String()
+	// Print Supported providers for reference when verbose flag is enabled
+	if verbose {
+		printSupportedProviders()
+	}
 
-	// loop through calls to the API until all pages of results have been fetched or limit has been reached:
-	var allSecretAlerts []Alert
-	var pageOfSecretAlerts []Alert
-	var pages = int(math.Ceil(float64(limit) / float64(per_page_int)))
+	// record the alerts, and their verification outcomes, in the local history:
+	store, err := openStateStore()
+	if err != nil {
+		return err
+	}
+	defer store.close()
+	runTime := time.Now()
 
-	opts := setOptions()
-	client, err := api.NewRESTClient(opts)
+	// request the alerts of each target, saving each page as it is fetched so that the run can be resumed
+	// if it stops part way through:
+	sources, err := newAlertSources(cmd.Name(), targets, values)
+	if err != nil {
+		return err
+	}
+	// write the alerts of this run to a snapshot as they arrive, for comparison with other runs:
+	snapshot, err := store.beginSnapshot(cmd.Name(), targets, sources, runTime)
 	if err != nil {
 		return err
 	}
 
-	for page := 1; page <= pages; page++ {
-		fmt.Println("Processing page: " + strconv.Itoa(page))
-		_, nextPage, err := callGitHubAPI(client, requestPath, &pageOfSecretAlerts, GET)
-		if err != nil {
+	// fetch pages of alerts in the background, verify which secret alerts are confirmed valid, and report on
+	// each page as it arrives:
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+	pages := make(chan []Alert, 1)
+	verifiedPages := make(chan []Alert, 1)
+	fetchErr := make(chan error, 1)
+	verifyErr := make(chan error, 1)
+	go func() {
+		fetchErr <- fetchAlertPages(ctx, client, sources, pages)
+	}()
+	go func() {
+		verifyErr <- verifyAlertPages(ctx, pages, verifiedPages)
+	}()
+
+	report := newAlertReport(client, reportScope(targets), true)
+	defer report.close()
+	// only alerts that end up in an issue, or are resolved, are kept once they have been reported, and
+	// without their secret values:
+	var issueAlerts []Alert
+	var inactiveAlerts []Alert
+	scannedRepos := map[string]bool{}
+	fetched := 0
+	for page := range verifiedPages {
+		fetched += len(page)
+		if err = report.add(page); err != nil {
+			return err
+		}
+		if err = store.record(page, runTime); err != nil {
 			return err
 		}
-		// add each secret alert in the response page to allSecretAlerts array
-		allSecretAlerts = append(allSecretAlerts, pageOfSecretAlerts...)
-		var hasNextPage bool
-		if requestPath, hasNextPage = findNextPage(nextPage); !hasNextPage {
-			break
+		if err = snapshot.add(page); err != nil {
+			return err
 		}
-		if page*per_page_int >= limit {
-			break
+		for _, alert := range page {
+			alert.Secret = ""
+			if createIssues {
+				scannedRepos[alert.Repository.Full_name] = true
+				if alert.Validity_outcome == OutcomeActive || alert.Validity_outcome == OutcomeUnknown {
+					issueAlerts = append(issueAlerts, alert)
+				}
+			}
+			if resolveInactive && alert.State == "open" && hasRevocationEvidence(alert) {
+				inactiveAlerts = append(inactiveAlerts, alert)
+			}
 		}
 	}
-
-	// sort allSecretAlerts by repository name, and then by secret alert ID:
-	sortedAlerts := sortAlerts(allSecretAlerts)
-
-	// if a specific repo endpoint was targeted, add the repo field to the alerts:
-	if repository != "" {
-		sortedAlerts = addRepoFullNameToAlerts(sortedAlerts)
+	if err = <-fetchErr; err != nil {
+		return err
+	}
+	if err = <-verifyErr; err != nil {
+		fmt.Fprintln(logOutput(), "WARNING: issues encountered while sending verify requests.")
 	}
 
-	// verify which secret alerts are confirmed valid:
-	verifiedAlerts, err := verifyAlerts(sortedAlerts)
+	// pretty print with validity status and optionally generate a csv report of the results:
+	err = report.finish()
 	if err != nil {
-		fmt.Println("WARNING: issues encountered while sending verify requests.")
+		fmt.Fprintln(logOutput(), err)
+		return err
 	}
-
-	// pretty print with validity status
-	if !quiet {
-		prettyPrintAlerts(verifiedAlerts, true)
+	if err = snapshot.finish(); err != nil {
+		return err
 	}
 
-	// optionally generate a csv report of the results:
-	if len(sortedAlerts) > 0 && csvReport {
-		err = generateCSVReport(sortedAlerts, scope, true)
+	// optionally resolve the alerts whose secrets were confirmed revoked:
+	if resolveInactive {
+		err = resolveInactiveAlerts(client, sortAlerts(inactiveAlerts))
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(logOutput(), err)
 			return err
 		}
 	}
 
 	// optionally create an issue for each repository that contains at least one valid secret alert:
 	if createIssues {
-		err = createIssuesForValidAlerts(verifiedAlerts)
+		// a repository whose last open alert was resolved isn't in the results any more, but its issue can
+		// still be closed by a run that fetched every open alert of the targets:
+		complete := coversAllOpenAlerts(fetched)
+		if complete {
+			repos, err := store.issueRepos(targets)
+			if err != nil {
+				return err
+			}
+			for _, repo := range repos {
+				scannedRepos[repo] = true
+			}
+		}
+		err = createIssuesForValidAlerts(client, store, sortAlerts(issueAlerts), slices.Collect(maps.Keys(scannedRepos)), complete)
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(logOutput(), err)
 			return err
 		}
 	}
-	return
+	return removeCheckpoints(sources)
 }